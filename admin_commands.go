@@ -0,0 +1,488 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- ADMIN COMMANDS -----------------
+
+// handleAdminCommand runs moderation commands sent in the admin group. It
+// reports false for commands it doesn't know so the regular handler can
+// answer them.
+func handleAdminCommand(msg *tgbotapi.Message) bool {
+	chatID := msg.Chat.ID
+	args := strings.Fields(msg.CommandArguments())
+
+	switch msg.Command() {
+	case "ban":
+		if userID, ok := parseAdminUserID(chatID, args, "/ban <user_id> [reason]"); ok {
+			adminStrike(msg, userID, StrikePermBan, 0, adminReason(args[1:]))
+		}
+
+	case "warn":
+		if userID, ok := parseAdminUserID(chatID, args, "/warn <user_id> [reason]"); ok {
+			adminStrike(msg, userID, StrikeWarning, 0, adminReason(args[1:]))
+		}
+
+	case "tempban":
+		userID, ok := parseAdminUserID(chatID, args, "/tempban <user_id> <duration, e.g. 3d or 12h> [reason]")
+		if !ok {
+			return true
+		}
+		banFor := cfg.TempBanDuration.Duration
+		reasonArgs := args[1:]
+		if len(args) > 1 {
+			if parsed, err := parseBanDuration(args[1]); err == nil {
+				banFor = parsed
+				reasonArgs = args[2:]
+			}
+		}
+		adminStrike(msg, userID, StrikeTempBan, banFor, adminReason(reasonArgs))
+
+	case "strike":
+		if userID, ok := parseAdminUserID(chatID, args, "/strike <user_id> [reason]"); ok {
+			adminStrike(msg, userID, nextStrikeKind(userID), cfg.TempBanDuration.Duration, adminReason(args[1:]))
+		}
+
+	case "strikes":
+		if userID, ok := parseAdminUserID(chatID, args, "/strikes <user_id>"); ok {
+			sendUserStrikes(chatID, userID)
+		}
+
+	case "unban":
+		if userID, ok := parseAdminUserID(chatID, args, "/unban <user_id>"); ok {
+			adminUnbanUser(chatID, userID)
+		}
+
+	case "userinfo":
+		if userID, ok := parseAdminUserID(chatID, args, "/userinfo <user_id>"); ok {
+			sendUserInfo(chatID, userID)
+		}
+
+	case "pending":
+		sendPendingConfessions(chatID)
+
+	case "reports":
+		if userID, ok := parseAdminUserID(chatID, args, "/reports <user_id>"); ok {
+			sendUserReports(chatID, userID)
+		}
+
+	case "delete":
+		if len(args) < 1 {
+			sendMessage(chatID, "ℹ️ *Usage:* `/delete <confession_id>`")
+			return true
+		}
+		confessionID, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil {
+			sendMessage(chatID, "❌ *Invalid confession ID*")
+			return true
+		}
+		adminDeleteConfession(chatID, confessionID)
+
+	case "stats":
+		sendAdminStats(chatID)
+
+	case "queue":
+		sendPublishingQueue(chatID)
+
+	case "postnow":
+		handlePostNowCommand(chatID, args)
+
+	case "hidecomment":
+		adminSetCommentStatus(chatID, args, "hidden", "/hidecomment <comment_id>")
+
+	case "showcomment":
+		adminSetCommentStatus(chatID, args, "visible", "/showcomment <comment_id>")
+
+	case "deletecomment":
+		adminSetCommentStatus(chatID, args, "deleted", "/deletecomment <comment_id>")
+
+	case "setlimit":
+		handleSetLimitCommand(msg, args)
+
+	case "limits":
+		if userID, ok := parseAdminUserID(chatID, args, "/limits <user_id>"); ok {
+			sendUserLimits(chatID, userID)
+		}
+
+	default:
+		return false
+	}
+	return true
+}
+
+func parseAdminUserID(chatID int64, args []string, usage string) (int64, bool) {
+	if len(args) < 1 {
+		sendMessage(chatID, fmt.Sprintf("ℹ️ *Usage:* `%s`", usage))
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		sendMessage(chatID, "❌ *Invalid user ID*")
+		return 0, false
+	}
+	return userID, true
+}
+
+// escapeMarkdown keeps user-provided text from breaking Markdown messages
+func escapeMarkdown(text string) string {
+	return tgbotapi.EscapeText(tgbotapi.ModeMarkdown, text)
+}
+
+func userExists(userID int64) bool {
+	var exists int
+	err := db.QueryRow("SELECT 1 FROM users WHERE user_id = ?", userID).Scan(&exists)
+	return err == nil
+}
+
+func adminReason(args []string) string {
+	if len(args) == 0 {
+		return "Violation of community guidelines"
+	}
+	return strings.Join(args, " ")
+}
+
+func adminStrike(msg *tgbotapi.Message, userID int64, kind string, banFor time.Duration, reason string) {
+	chatID := msg.Chat.ID
+	if !userExists(userID) {
+		sendMessage(chatID, fmt.Sprintf("❌ *Unknown user* `%d`", userID))
+		return
+	}
+
+	if err := applyStrike(userID, kind, reason, "admin", msg.From.ID, banFor); err != nil {
+		log.Println("Error issuing strike:", err)
+		sendMessage(chatID, "❌ *Error issuing strike*")
+		return
+	}
+
+	duration := "—"
+	switch kind {
+	case StrikeTempBan:
+		duration = formatBanDuration(banFor)
+	case StrikePermBan:
+		duration = "Permanent"
+	}
+
+	sendMessage(chatID, fmt.Sprintf("⚖️ *STRIKE ISSUED*\n──────────────\n\n"+
+		"👤 *User ID:* `%d`\n"+
+		"⚖️ *Action:* %s\n"+
+		"⏳ *Duration:* %s\n"+
+		"📋 *Reason:* %s",
+		userID, strikeKindText(kind), duration, escapeMarkdown(reason)))
+}
+
+func sendUserStrikes(chatID int64, userID int64) {
+	rows, err := db.Query(`
+		SELECT kind, COALESCE(reason, ''), source, created_at
+		FROM strikes
+		WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT 20`, userID)
+	if err != nil {
+		log.Println("Error loading strikes:", err)
+		sendMessage(chatID, "❌ *Error loading strikes*")
+		return
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var kind, reason, source string
+		var createdAt time.Time
+		if err := rows.Scan(&kind, &reason, &source, &createdAt); err != nil {
+			log.Println("Error scanning strike:", err)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("• %s · %s · %s\n  %s",
+			strikeKindText(kind), source, createdAt.Format("Jan 2, 3:04 PM"), escapeMarkdown(reason)))
+	}
+
+	if len(lines) == 0 {
+		sendMessage(chatID, fmt.Sprintf("✅ *No strikes for* `%d`", userID))
+		return
+	}
+
+	sendMessage(chatID, fmt.Sprintf("⚖️ *STRIKES FOR* `%d`\n──────────────\n\n%s\n\n*Next strike:* %s",
+		userID, strings.Join(lines, "\n"), strikeKindText(nextStrikeKind(userID))))
+}
+
+func adminUnbanUser(chatID int64, userID int64) {
+	found, err := unbanUser(userID)
+	if err != nil {
+		log.Println("Error unbanning user:", err)
+		sendMessage(chatID, "❌ *Error unbanning user*")
+		return
+	}
+	if !found {
+		sendMessage(chatID, fmt.Sprintf("❌ *Unknown user* `%d`", userID))
+		return
+	}
+
+	sendMessage(chatID, fmt.Sprintf("✅ *USER UNBANNED*\n──────────────\n\n👤 *User ID:* `%d`", userID))
+	sendMessage(userID, "✅ *Access Restored*\n\nYour account restriction has been lifted. Welcome back!")
+}
+
+func sendUserInfo(chatID int64, userID int64) {
+	var username, firstName, gender sql.NullString
+	var banned int
+	var createdAt time.Time
+	err := db.QueryRow(`
+		SELECT username, first_name, gender, banned, created_at
+		FROM users WHERE user_id = ?`, userID).Scan(
+		&username, &firstName, &gender, &banned, &createdAt)
+	if err == sql.ErrNoRows {
+		sendMessage(chatID, fmt.Sprintf("❌ *Unknown user* `%d`", userID))
+		return
+	}
+	if err != nil {
+		log.Println("Error loading user info:", err)
+		sendMessage(chatID, "❌ *Error loading user*")
+		return
+	}
+
+	var total, approved, pending, rejected int
+	db.QueryRow(`
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN status = 'approved' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status = 'rejected' THEN 1 ELSE 0 END), 0)
+		FROM confessions WHERE user_id = ?`, userID).Scan(&total, &approved, &pending, &rejected)
+
+	var reportsFiled int
+	db.QueryRow("SELECT COUNT(*) FROM reports WHERE reporter_id = ?", userID).Scan(&reportsFiled)
+
+	status := "✅ Active"
+	if banned == 1 {
+		status = "🚫 Banned"
+		if until, ok := banExpiry(userID); ok {
+			status = fmt.Sprintf("⏳ Banned until %s", until.Format("Jan 2, 3:04 PM"))
+		}
+	}
+
+	var strikeCount int
+	db.QueryRow("SELECT COUNT(*) FROM strikes WHERE user_id = ?", userID).Scan(&strikeCount)
+
+	blindStatus := "No profile"
+	if profile, err := getBlindProfile(userID); err == nil && profile.ProfileSet {
+		blindStatus = "Profile set"
+	}
+	if sessions.InPair(userID) {
+		blindStatus += ", in a chat"
+	} else if sessions.IsWaiting(userID) {
+		blindStatus += ", searching"
+	}
+
+	sendMessage(chatID, fmt.Sprintf("👤 *USER INFO*\n──────────────\n\n"+
+		"🆔 *User ID:* `%d`\n"+
+		"📛 *Username:* %s\n"+
+		"🙂 *Name:* %s\n"+
+		"🎭 *Gender:* %s\n"+
+		"📅 *Joined:* %s\n"+
+		"🔒 *Status:* %s\n\n"+
+		"📝 *Confessions:* %d (✅ %d · ⏳ %d · ❌ %d)\n"+
+		"🚨 *Reports against:* %d/%d\n"+
+		"📋 *Reports filed:* %d\n"+
+		"⚖️ *Strikes:* %d\n"+
+		"💝 *Blind connections:* %s",
+		userID,
+		escapeMarkdown(orDash(username.String)),
+		escapeMarkdown(orDash(firstName.String)),
+		orDash(gender.String),
+		createdAt.Format("Jan 2, 2006"),
+		status,
+		total, approved, pending, rejected,
+		getReportCount(userID), cfg.ReportBanThreshold,
+		reportsFiled,
+		strikeCount,
+		blindStatus))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+func sendPendingConfessions(chatID int64) {
+	rows, err := db.Query(`
+		SELECT id, user_id, type, COALESCE(text, ''), date
+		FROM confessions
+		WHERE status = 'pending'
+		ORDER BY id
+		LIMIT 20`)
+	if err != nil {
+		log.Println("Error loading pending confessions:", err)
+		sendMessage(chatID, "❌ *Error loading pending confessions*")
+		return
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var id int
+		var userID int64
+		var confessionType, text string
+		var date time.Time
+		if err := rows.Scan(&id, &userID, &confessionType, &text, &date); err != nil {
+			log.Println("Error scanning pending confession:", err)
+			continue
+		}
+
+		preview := truncateText(text, 60)
+		switch confessionType {
+		case "voice":
+			preview = "🎤 Voice confession"
+		case "photo":
+			preview = "📸 Photo · " + truncateText(text, 50)
+		}
+		lines = append(lines, fmt.Sprintf("*#%d* · `%d` · %s\n%s",
+			id, userID, date.Format("Jan 2, 3:04 PM"), escapeMarkdown(preview)))
+	}
+
+	if len(lines) == 0 {
+		sendMessage(chatID, "✅ *No pending confessions*\n\nThe review queue is empty.")
+		return
+	}
+
+	var pendingCount int
+	db.QueryRow("SELECT COUNT(*) FROM confessions WHERE status = 'pending'").Scan(&pendingCount)
+
+	sendMessage(chatID, fmt.Sprintf("⏳ *PENDING CONFESSIONS* (%d)\n──────────────\n\n%s",
+		pendingCount, strings.Join(lines, "\n\n")))
+}
+
+// truncateText shortens text to at most limit runes, adding an ellipsis
+func truncateText(text string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit]) + "…"
+}
+
+func sendUserReports(chatID int64, userID int64) {
+	rows, err := db.Query(`
+		SELECT reporter_id, COALESCE(reason, ''), COALESCE(context, ''), created_at
+		FROM reports
+		WHERE reported_id = ?
+		ORDER BY created_at DESC
+		LIMIT 20`, userID)
+	if err != nil {
+		log.Println("Error loading reports:", err)
+		sendMessage(chatID, "❌ *Error loading reports*")
+		return
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var reporterID int64
+		var reason, context string
+		var createdAt time.Time
+		if err := rows.Scan(&reporterID, &reason, &context, &createdAt); err != nil {
+			log.Println("Error scanning report:", err)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("• *%s* (%s) by `%d` · %s",
+			escapeMarkdown(reason), escapeMarkdown(context), reporterID, createdAt.Format("Jan 2, 3:04 PM")))
+	}
+
+	if len(lines) == 0 {
+		sendMessage(chatID, fmt.Sprintf("✅ *No reports against* `%d`", userID))
+		return
+	}
+
+	sendMessage(chatID, fmt.Sprintf("🚨 *REPORTS AGAINST* `%d` (%d/%d)\n──────────────\n\n%s",
+		userID, getReportCount(userID), cfg.ReportBanThreshold, strings.Join(lines, "\n")))
+}
+
+func adminDeleteConfession(chatID int64, confessionID int) {
+	var channelMessageID sql.NullInt64
+	var status string
+	err := db.QueryRow(`
+		SELECT channel_message_id, status
+		FROM confessions WHERE id = ?`, confessionID).Scan(&channelMessageID, &status)
+	if err == sql.ErrNoRows {
+		sendMessage(chatID, fmt.Sprintf("❌ *Unknown confession* #%d", confessionID))
+		return
+	}
+	if err != nil {
+		log.Println("Error loading confession:", err)
+		sendMessage(chatID, "❌ *Error loading confession*")
+		return
+	}
+	if status == "deleted" {
+		sendMessage(chatID, fmt.Sprintf("ℹ️ *Confession #%d is already deleted*", confessionID))
+		return
+	}
+
+	if channelMessageID.Valid && channelMessageID.Int64 != 0 {
+		_, err := bot.Request(tgbotapi.NewDeleteMessage(channelID, int(channelMessageID.Int64)))
+		if err != nil {
+			log.Println("Error deleting channel post:", err)
+			sendMessage(chatID, fmt.Sprintf("❌ *Could not remove #%d from the channel*\n\n`%s`", confessionID, err.Error()))
+			return
+		}
+	}
+
+	_, err = db.Exec(`
+		UPDATE confessions
+		SET status = 'deleted', approved = 0
+		WHERE id = ?`, confessionID)
+	if err != nil {
+		log.Println("Error marking confession deleted:", err)
+	}
+
+	sendMessage(chatID, fmt.Sprintf("🗑️ *CONFESSION DELETED* #%d\n──────────────\n\nRemoved from the channel.", confessionID))
+}
+
+func sendAdminStats(chatID int64) {
+	var users, bannedUsers, profiles int
+	db.QueryRow("SELECT COUNT(*), COALESCE(SUM(banned), 0) FROM users").Scan(&users, &bannedUsers)
+	db.QueryRow("SELECT COUNT(*) FROM blind_profiles WHERE profile_set = 1").Scan(&profiles)
+
+	var confessions, approved, queued, pending, rejected, voice, photo int
+	db.QueryRow(`
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN status = 'approved' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status = 'queued' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status = 'rejected' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN type = 'voice' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN type = 'photo' THEN 1 ELSE 0 END), 0)
+		FROM confessions`).Scan(&confessions, &approved, &queued, &pending, &rejected, &voice, &photo)
+
+	var today int
+	db.QueryRow("SELECT COUNT(*) FROM confessions WHERE date >= datetime('now', '-1 day')").Scan(&today)
+
+	var reports, comments, reactions int
+	db.QueryRow("SELECT COUNT(*) FROM reports").Scan(&reports)
+	db.QueryRow("SELECT COUNT(*) FROM confession_comments").Scan(&comments)
+	db.QueryRow("SELECT COUNT(*) FROM confession_reactions").Scan(&reactions)
+
+	var activeChats int
+	db.QueryRow("SELECT COUNT(*) / 2 FROM blind_pairs").Scan(&activeChats)
+
+	sendMessage(chatID, fmt.Sprintf("📊 *BOT STATISTICS*\n──────────────\n\n"+
+		"👥 *Users:* %d (🚫 %d banned)\n"+
+		"💝 *Blind profiles:* %d\n"+
+		"💬 *Active blind chats:* %d\n"+
+		"🔍 *Searching now:* %d\n\n"+
+		"📝 *Confessions:* %d (🎤 %d voice, 📸 %d photo)\n"+
+		"✅ Posted: %d · 🗓️ Queued: %d · ⏳ Pending: %d · ❌ Rejected: %d\n"+
+		"🕐 *Last 24h:* %d\n\n"+
+		"💬 *Comments:* %d\n"+
+		"❤️ *Reactions:* %d\n"+
+		"🚨 *Reports:* %d",
+		users, bannedUsers, profiles, activeChats, len(sessions.WaitingUsers()),
+		confessions, voice, photo, approved, queued, pending, rejected, today,
+		comments, reactions, reports))
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- ADMIN CONTACT THREADS -----------------
+
+// Contact threads start with an admin_contacts row. Admins answer through
+// the bot so their accounts stay hidden, and users can answer back until an
+// admin closes the thread. Status is pending (waiting for admins), answered
+// (waiting for the user) or closed.
+
+func addContactMessage(contactID int64, sender string, adminID int64, text string) error {
+	_, err := db.Exec(`
+		INSERT INTO admin_contact_messages (contact_id, sender, admin_id, text)
+		VALUES (?, ?, ?, ?)`, contactID, sender, adminID, text)
+	return err
+}
+
+func setContactStatus(contactID int64, status string) {
+	_, err := db.Exec(`
+		UPDATE admin_contacts SET status = ?, updated_at = datetime('now')
+		WHERE id = ?`, status, contactID)
+	if err != nil {
+		log.Println("Error updating contact status:", err)
+	}
+}
+
+// forwardContactToAdmins posts a user's message to the admin group with the
+// thread buttons and remembers it as the thread's latest admin message.
+func forwardContactToAdmins(contactID int64, userID int64, username string, text string, followUp bool) {
+	title := "📬 *ADMIN MESSAGE*"
+	if followUp {
+		title = "↩️ *USER FOLLOW-UP*"
+	}
+
+	adminMsg := tgbotapi.NewMessage(adminGroupID,
+		fmt.Sprintf("%s · Ticket #%d\n──────────────\n\n"+
+			"👤 *From:* `%d`\n"+
+			"📱 *Username:* @%s\n"+
+			"🕐 *Time:* %s\n\n"+
+			"💭 *Message:*\n%s\n\n"+
+			"──────────────",
+			title, contactID, userID, escapeMarkdown(username),
+			time.Now().Format("Jan 2, 3:04 PM"), escapeMarkdown(text)))
+	adminMsg.ParseMode = "Markdown"
+	adminMsg.ReplyMarkup = createContactAdminKeyboard(contactID)
+
+	sent, err := bot.Send(adminMsg)
+	if err != nil {
+		log.Println("Error forwarding contact to admins:", err)
+		return
+	}
+	db.Exec("UPDATE admin_contacts SET admin_message_id = ? WHERE id = ?", sent.MessageID, contactID)
+}
+
+func createContactAdminKeyboard(contactID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💬 Reply", fmt.Sprintf("contact:reply:%d", contactID)),
+			tgbotapi.NewInlineKeyboardButtonData("📜 History", fmt.Sprintf("contact:history:%d", contactID)),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Close", fmt.Sprintf("contact:close:%d", contactID)),
+		),
+	)
+}
+
+func handleContactCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 3 || cb.Message == nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	contactID, _ := strconv.ParseInt(parts[2], 10, 64)
+
+	var userID int64
+	var status string
+	err := db.QueryRow("SELECT user_id, status FROM admin_contacts WHERE id = ?", contactID).Scan(&userID, &status)
+	if err != nil {
+		log.Println("Error getting contact:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Ticket not found"))
+		return
+	}
+
+	// The user's own button; everything else is for admins only
+	if parts[1] == "followup" {
+		startContactFollowUp(cb, contactID, userID, status)
+		return
+	}
+	if cb.Message.Chat.ID != adminGroupID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	switch parts[1] {
+	case "reply":
+		if status == "closed" {
+			bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Ticket is closed"))
+			return
+		}
+		sessions.StartStep(cb.From.ID, "contact_reply", map[string]interface{}{
+			"contact_id": contactID,
+		})
+
+		// ForceReply lets the answer reach the bot even with group privacy mode on
+		prompt := tgbotapi.NewMessage(adminGroupID,
+			fmt.Sprintf("✍️ [%s](tg://user?id=%d), reply to this message with your answer to ticket #%d.\n\n"+
+				"It will be sent anonymously. Send /cancel to stop.",
+				escapeMarkdown(cb.From.FirstName), cb.From.ID, contactID))
+		prompt.ParseMode = "Markdown"
+		prompt.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+		sent, err := bot.Send(prompt)
+		if err != nil {
+			log.Println("Error sending contact reply prompt:", err)
+			sessions.ClearState(cb.From.ID)
+			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+			return
+		}
+		// Only a reply to this prompt goes to the user
+		sessions.SetData(cb.From.ID, "prompt_id", sent.MessageID)
+
+		bot.Send(tgbotapi.NewCallback(cb.ID, "✍️ Write your reply"))
+
+	case "history":
+		sendContactHistory(contactID)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "📜 History sent"))
+
+	case "close":
+		if status == "closed" {
+			bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already closed"))
+			return
+		}
+		setContactStatus(contactID, "closed")
+
+		editMarkup := tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		bot.Send(editMarkup)
+
+		sendMessage(adminGroupID, fmt.Sprintf("✅ *Ticket #%d closed* by @%s", contactID, escapeMarkdown(cb.From.UserName)))
+		sendMessage(userID,
+			fmt.Sprintf("✅ *Ticket #%d Closed*\n\nThe admin team closed this conversation. "+
+				"Use 📞 Contact Admin if you need anything else.", contactID))
+
+		bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Closed"))
+
+	default:
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown action"))
+	}
+}
+
+// handleAdminContactReply relays an admin's answer to the ticket owner.
+// Only a reply to the prompt is sent; other chatter in the group stays there.
+func handleAdminContactReply(adminID int64, chatID int64, msg *tgbotapi.Message) {
+	rawContactID, _ := sessions.GetData(adminID, "contact_id")
+	contactID, _ := rawContactID.(int64)
+	rawPromptID, _ := sessions.GetData(adminID, "prompt_id")
+	promptID, _ := rawPromptID.(int)
+
+	// Any command ends the reply; /cancel@BotName is the usual form in groups,
+	// and other commands still run instead of reaching the user
+	if msg.IsCommand() || msg.Text == "❌ Cancel" || chatID != adminGroupID {
+		sessions.ClearState(adminID)
+		sendMessage(chatID, fmt.Sprintf("❌ *Reply cancelled* (ticket #%d)", contactID))
+		if msg.IsCommand() && msg.Command() != "cancel" {
+			handleCommand(msg)
+		}
+		return
+	}
+	if msg.ReplyToMessage == nil || msg.ReplyToMessage.MessageID != promptID {
+		return
+	}
+	sessions.ClearState(adminID)
+	if msg.Text == "" {
+		sendMessage(chatID, "❌ *Text Only*\n\nPress 💬 Reply again and answer with text.")
+		return
+	}
+
+	var userID int64
+	var status string
+	err := db.QueryRow("SELECT user_id, status FROM admin_contacts WHERE id = ?", contactID).Scan(&userID, &status)
+	if err != nil {
+		log.Println("Error getting contact:", err)
+		sendMessage(chatID, "❌ *Ticket not found*")
+		return
+	}
+	if status == "closed" {
+		sendMessage(chatID, fmt.Sprintf("ℹ️ *Ticket #%d is closed*", contactID))
+		return
+	}
+
+	if err := addContactMessage(contactID, "admin", adminID, msg.Text); err != nil {
+		log.Println("Error saving contact reply:", err)
+		sendMessage(chatID, "❌ *Error saving reply*")
+		return
+	}
+	setContactStatus(contactID, "answered")
+
+	userMsg := tgbotapi.NewMessage(userID,
+		fmt.Sprintf("📬 *REPLY FROM THE ADMIN TEAM*\n──────────────\n\n"+
+			"📜 *Ticket:* #%d\n\n"+
+			"💭 %s\n\n"+
+			"──────────────\n"+
+			"Tap ↩️ Reply to answer in this thread.",
+			contactID, escapeMarkdown(msg.Text)))
+	userMsg.ParseMode = "Markdown"
+	userMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Reply", fmt.Sprintf("contact:followup:%d", contactID)),
+		),
+	)
+	if _, err := bot.Send(userMsg); err != nil {
+		log.Println("Error relaying admin reply:", err)
+		sendMessage(chatID, fmt.Sprintf("❌ *Could not deliver reply to ticket #%d*", contactID))
+		return
+	}
+
+	sendMessage(chatID, fmt.Sprintf("✅ *Reply sent* to ticket #%d", contactID))
+}
+
+func startContactFollowUp(cb *tgbotapi.CallbackQuery, contactID int64, ownerID int64, status string) {
+	userID := cb.From.ID
+	if userID != ownerID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	if status == "closed" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ This ticket is closed"))
+		return
+	}
+
+	sessions.StartStep(userID, "contact_followup", map[string]interface{}{
+		"contact_id": contactID,
+	})
+	bot.Send(tgbotapi.NewCallback(cb.ID, "✍️ Write your reply"))
+
+	cancelKeyboard := createCancelKeyboard()
+	sessions.SetKeyboard(userID, cancelKeyboard)
+	sendMessageWithKeyboard(userID,
+		fmt.Sprintf("↩️ *Reply to Ticket #%d*\n\n📝 *Write your message below:*", contactID),
+		cancelKeyboard)
+}
+
+// handleContactFollowUp sends the user's answer back into their thread.
+func handleContactFollowUp(userID int64, chatID int64, msg *tgbotapi.Message) {
+	if msg.Text == "❌ Cancel" {
+		sessions.ClearState(userID)
+		mainMenuKeyboard := createMainMenuKeyboard()
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID,
+			"❌ *Cancelled*\n\nReply cancelled.",
+			mainMenuKeyboard)
+		return
+	}
+	if msg.Text == "" {
+		sendMessageWithKeyboard(chatID,
+			"❌ *Text Only*\n\nPlease write your reply as text.",
+			createCancelKeyboard())
+		return
+	}
+
+	rawContactID, _ := sessions.GetData(userID, "contact_id")
+	contactID, _ := rawContactID.(int64)
+	sessions.ClearState(userID)
+
+	var status string
+	err := db.QueryRow(`
+		SELECT status FROM admin_contacts
+		WHERE id = ? AND user_id = ?`, contactID, userID).Scan(&status)
+	if err == sql.ErrNoRows || status == "closed" {
+		sendMessage(chatID, "ℹ️ *Ticket Closed*\n\nThis conversation has ended. Use 📞 Contact Admin to start a new one.")
+		return
+	}
+	if err != nil {
+		log.Println("Error getting contact:", err)
+		sendMessage(chatID, "❌ *Error*\n\nFailed to send your reply. Please try again.")
+		return
+	}
+
+	if err := addContactMessage(contactID, "user", 0, msg.Text); err != nil {
+		log.Println("Error saving contact follow-up:", err)
+		sendMessage(chatID, "❌ *Error*\n\nFailed to send your reply. Please try again.")
+		return
+	}
+	setContactStatus(contactID, "pending")
+
+	forwardContactToAdmins(contactID, userID, msg.From.UserName, msg.Text, true)
+
+	mainMenuKeyboard := createMainMenuKeyboard()
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
+	sendMessageWithKeyboard(chatID,
+		fmt.Sprintf("✅ *Reply Sent*\n\nYour message was added to ticket #%d.", contactID),
+		mainMenuKeyboard)
+}
+
+func sendContactHistory(contactID int64) {
+	rows, err := db.Query(`
+		SELECT sender, text, created_at
+		FROM admin_contact_messages
+		WHERE contact_id = ?
+		ORDER BY id`, contactID)
+	if err != nil {
+		log.Println("Error loading contact history:", err)
+		return
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var sender, text string
+		var createdAt time.Time
+		if err := rows.Scan(&sender, &text, &createdAt); err != nil {
+			log.Println("Error scanning contact message:", err)
+			continue
+		}
+		who := "👤 User"
+		if sender == "admin" {
+			who = "🛡️ Admin"
+		}
+		lines = append(lines, fmt.Sprintf("*%s* · %s\n%s", who, createdAt.Format("Jan 2, 3:04 PM"), escapeMarkdown(text)))
+	}
+
+	if len(lines) == 0 {
+		sendMessage(adminGroupID, fmt.Sprintf("📜 *Ticket #%d* has no messages", contactID))
+		return
+	}
+
+	sendMessage(adminGroupID, fmt.Sprintf("📜 *TICKET #%d HISTORY*\n──────────────\n\n%s",
+		contactID, strings.Join(lines, "\n\n")))
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- BAN APPEALS -----------------
+
+const appealMinLength = 10
+
+// startAppeal asks a banned user to explain why they should be unbanned.
+// It is reachable while banned; handleMessage lets /appeal through.
+func startAppeal(userID int64, chatID int64) {
+	if chatID != userID {
+		sendMessage(chatID, "🔒 *Private Only*\n\nPlease send appeals in private chat.")
+		return
+	}
+
+	if !isBanned(userID) {
+		sendMessage(chatID, "✅ *No Restriction*\n\nYour account isn't restricted, so there is nothing to appeal.")
+		return
+	}
+
+	if appealID, ok := pendingAppeal(userID); ok {
+		sendMessage(chatID,
+			fmt.Sprintf("⏳ *Appeal In Review*\n\nYour appeal #%d is still waiting for an admin. We'll message you once it's decided.", appealID))
+		return
+	}
+
+	sessions.StartStep(userID, "appeal", nil)
+
+	cancelKeyboard := createCancelKeyboard()
+	sessions.SetKeyboard(chatID, cancelKeyboard)
+	sendMessageWithKeyboard(chatID,
+		"⚖️ *Ban Appeal*\n──────────────\n\n"+
+			"✨ *Tell the admin team why your restriction should be lifted*\n\n"+
+			"📝 *Write your appeal below:*\n"+
+			"• What happened\n"+
+			"• Why you think the ban was a mistake\n\n"+
+			"──────────────\n"+
+			"*Note:* One open appeal at a time",
+		cancelKeyboard)
+}
+
+func handleAppealMessage(userID int64, chatID int64, msg *tgbotapi.Message) {
+	if msg.Text == "❌ Cancel" {
+		sessions.ClearState(userID)
+		mainMenuKeyboard := createMainMenuKeyboard()
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID,
+			"❌ *Cancelled*\n\nAppeal cancelled.",
+			mainMenuKeyboard)
+		return
+	}
+
+	text := strings.TrimSpace(msg.Text)
+	if len([]rune(text)) < appealMinLength {
+		sendMessageWithKeyboard(chatID,
+			fmt.Sprintf("❌ *Too Short*\n\nPlease explain your appeal in at least %d characters.", appealMinLength),
+			createCancelKeyboard())
+		return
+	}
+
+	sessions.ClearState(userID)
+
+	if !isBanned(userID) {
+		sendMessage(chatID, "✅ *No Restriction*\n\nYour account isn't restricted anymore.")
+		return
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO appeals (user_id, message)
+		VALUES (?, ?)`, userID, text)
+	if err != nil {
+		log.Println("Error saving appeal:", err)
+		sendMessage(chatID, "❌ *Error*\n\nFailed to send your appeal. Please try again.")
+		return
+	}
+	appealID, _ := result.LastInsertId()
+
+	adminMsg := tgbotapi.NewMessage(adminGroupID,
+		fmt.Sprintf("⚖️ *BAN APPEAL* #%d\n──────────────\n\n"+
+			"👤 *From:* `%d`\n"+
+			"📱 *Username:* @%s\n"+
+			"🚨 *Reports against:* %d\n"+
+			"🕐 *Time:* %s\n\n"+
+			"💭 *Appeal:*\n%s\n\n"+
+			"──────────────",
+			appealID, userID, escapeMarkdown(msg.From.UserName), getReportCount(userID),
+			time.Now().Format("Jan 2, 3:04 PM"), escapeMarkdown(text)))
+	adminMsg.ParseMode = "Markdown"
+	adminMsg.ReplyMarkup = createAppealKeyboard(appealID)
+	if sent, err := bot.Send(adminMsg); err == nil {
+		db.Exec("UPDATE appeals SET admin_message_id = ? WHERE id = ?", sent.MessageID, appealID)
+	} else {
+		log.Println("Error sending appeal to admins:", err)
+	}
+
+	mainMenuKeyboard := createMainMenuKeyboard()
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
+	sendMessageWithKeyboard(chatID,
+		fmt.Sprintf("✅ *Appeal Submitted*\n──────────────\n\n"+
+			"📜 *Appeal ID:* #%d\n"+
+			"⏳ *Status:* Waiting for review\n\n"+
+			"──────────────\n"+
+			"We'll message you once an admin decides.", appealID),
+		mainMenuKeyboard)
+}
+
+func pendingAppeal(userID int64) (int64, bool) {
+	var appealID int64
+	err := db.QueryRow(`
+		SELECT id FROM appeals
+		WHERE user_id = ? AND status = 'pending'
+		ORDER BY id DESC LIMIT 1`, userID).Scan(&appealID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("Error checking appeals:", err)
+	}
+	return appealID, err == nil
+}
+
+func createAppealKeyboard(appealID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Accept", fmt.Sprintf("appeal:accept:%d", appealID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Deny", fmt.Sprintf("appeal:deny:%d", appealID)),
+		),
+	)
+}
+
+func handleAppealCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 3 || cb.Message == nil || cb.Message.Chat.ID != adminGroupID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	decision := parts[1]
+	appealID, _ := strconv.ParseInt(parts[2], 10, 64)
+
+	status := "denied"
+	if decision == "accept" {
+		status = "accepted"
+	}
+
+	var userID int64
+	var message string
+	err := db.QueryRow("SELECT user_id, message FROM appeals WHERE id = ?", appealID).Scan(&userID, &message)
+	if err != nil {
+		log.Println("Error getting appeal:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	// Only the first admin decision counts
+	result, err := db.Exec(`
+		UPDATE appeals
+		SET status = ?, resolved_by = ?, resolved_at = datetime('now')
+		WHERE id = ? AND status = 'pending'`, status, cb.From.ID, appealID)
+	if err != nil {
+		log.Println("Error resolving appeal:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already decided"))
+		return
+	}
+
+	statusText := "❌ *APPEAL DENIED*"
+	if status == "accepted" {
+		statusText = "✅ *APPEAL ACCEPTED*"
+
+		if _, err := unbanUser(userID); err != nil {
+			log.Println("Error unbanning user:", err)
+		}
+
+		sendMessage(userID,
+			fmt.Sprintf("✅ *APPEAL ACCEPTED*\n──────────────\n\n"+
+				"📜 *Appeal ID:* #%d\n"+
+				"🔓 *Status:* Restriction lifted\n\n"+
+				"──────────────\n"+
+				"Welcome back! Please keep our guidelines in mind.", appealID))
+	} else {
+		sendMessage(userID,
+			fmt.Sprintf("❌ *APPEAL DENIED*\n──────────────\n\n"+
+				"📜 *Appeal ID:* #%d\n"+
+				"🔒 *Status:* Restriction stays in place\n\n"+
+				"──────────────\n"+
+				"You can send a new appeal with /appeal later.", appealID))
+	}
+
+	editMsg := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID,
+		fmt.Sprintf("%s #%d\n──────────────\n\n"+
+			"👤 *User ID:* `%d`\n"+
+			"👮 *Decided by:* @%s\n"+
+			"🕐 *Time:* %s\n\n"+
+			"💭 *Appeal:*\n%s",
+			statusText, appealID, userID, escapeMarkdown(cb.From.UserName),
+			time.Now().Format("3:04 PM"), escapeMarkdown(message)))
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+
+	if status == "accepted" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Accepted"))
+	} else {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Denied"))
+	}
+}
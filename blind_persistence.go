@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// ----------------- BLIND CHAT PERSISTENCE -----------------
+
+func saveBlindPair(userID int64, userSide BlindChatPair, partnerID int64, partnerSide BlindChatPair) {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Println("Error saving blind pair:", err)
+		return
+	}
+	defer tx.Rollback()
+
+	for ownerID, side := range map[int64]BlindChatPair{userID: userSide, partnerID: partnerSide} {
+		_, err = tx.Exec(`
+			INSERT OR REPLACE INTO blind_pairs (user_id, partner_id, partner_username, partner_name)
+			VALUES (?, ?, ?, ?)`,
+			ownerID, side.PartnerID, side.PartnerUsername, side.PartnerName)
+		if err != nil {
+			log.Println("Error saving blind pair:", err)
+			return
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM blind_queue WHERE user_id IN (?, ?)", userID, partnerID)
+	if err != nil {
+		log.Println("Error clearing blind queue:", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("Error saving blind pair:", err)
+	}
+}
+
+func deleteBlindPair(userID int64, partnerID int64) {
+	_, err := db.Exec("DELETE FROM blind_pairs WHERE user_id IN (?, ?)", userID, partnerID)
+	if err != nil {
+		log.Println("Error deleting blind pair:", err)
+	}
+}
+
+func saveQueueEntry(userID int64) {
+	_, err := db.Exec(`
+		INSERT OR IGNORE INTO blind_queue (user_id)
+		VALUES (?)`, userID)
+	if err != nil {
+		log.Println("Error saving queue entry:", err)
+	}
+}
+
+func deleteQueueEntry(userID int64) {
+	_, err := db.Exec("DELETE FROM blind_queue WHERE user_id = ?", userID)
+	if err != nil {
+		log.Println("Error deleting queue entry:", err)
+	}
+}
+
+func getReportCount(userID int64) int {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM reports WHERE reported_id = ?", userID).Scan(&count)
+	if err != nil {
+		log.Println("Error counting reports:", err)
+	}
+	return count
+}
+
+// restoreBlindChats reloads active pairs and the waiting queue after a restart
+// and lets everyone involved know their chat or search is still on.
+func restoreBlindChats() {
+	rows, err := db.Query(`
+		SELECT user_id, partner_id, partner_username, partner_name
+		FROM blind_pairs`)
+	if err != nil {
+		log.Println("Error loading blind pairs:", err)
+		return
+	}
+
+	sides := make(map[int64]BlindChatPair)
+	for rows.Next() {
+		var userID int64
+		var side BlindChatPair
+		var partnerUsername, partnerName *string
+		if err := rows.Scan(&userID, &side.PartnerID, &partnerUsername, &partnerName); err != nil {
+			log.Println("Error scanning blind pair:", err)
+			continue
+		}
+		if partnerUsername != nil {
+			side.PartnerUsername = *partnerUsername
+		}
+		if partnerName != nil {
+			side.PartnerName = *partnerName
+		}
+		sides[userID] = side
+	}
+	rows.Close()
+
+	var restoredPairs int
+	for userID, side := range sides {
+		partnerSide, ok := sides[side.PartnerID]
+		if !ok || partnerSide.PartnerID != userID {
+			// Half a pair is useless; drop it
+			deleteBlindPair(userID, side.PartnerID)
+			continue
+		}
+
+		if userID > side.PartnerID {
+			continue // each pair is handled once, from its lower ID
+		}
+
+		sessions.Pair(userID, side, side.PartnerID, partnerSide)
+		sessions.Touch(userID)
+		sessions.Touch(side.PartnerID)
+		restoredPairs++
+
+		romanticKeyboard := createRomanticChatKeyboard()
+		sendMessageWithKeyboard(userID,
+			fmt.Sprintf("✨ *We're Back!*\n──────────────\n\n"+
+				"💬 *Your chat with %s is still active*\n\n"+
+				"The bot restarted briefly. Messages sent during the restart may not have been delivered.",
+				side.PartnerUsername),
+			romanticKeyboard)
+		sendMessageWithKeyboard(side.PartnerID,
+			fmt.Sprintf("✨ *We're Back!*\n──────────────\n\n"+
+				"💬 *Your chat with %s is still active*\n\n"+
+				"The bot restarted briefly. Messages sent during the restart may not have been delivered.",
+				partnerSide.PartnerUsername),
+			romanticKeyboard)
+	}
+
+	rows, err = db.Query("SELECT user_id, joined_at FROM blind_queue")
+	if err != nil {
+		log.Println("Error loading blind queue:", err)
+		log.Printf("💝 Restored %d blind pairs", restoredPairs)
+		return
+	}
+
+	queued := make(map[int64]time.Time)
+	for rows.Next() {
+		var userID int64
+		var joinedAt time.Time
+		if err := rows.Scan(&userID, &joinedAt); err != nil {
+			log.Println("Error scanning queue entry:", err)
+			continue
+		}
+		queued[userID] = joinedAt
+	}
+	rows.Close()
+
+	var restoredWaiting int
+	for userID, joinedAt := range queued {
+		if sessions.InPair(userID) {
+			deleteQueueEntry(userID)
+			continue
+		}
+
+		// Keep the original join time so the wait-time bonus survives restarts
+		sessions.JoinWaiting(userID, joinedAt)
+		sessions.Touch(userID)
+		restoredWaiting++
+
+		sendMessageWithKeyboard(userID,
+			"✨ *We're Back!*\n──────────────\n\n"+
+				"🔍 *You're still in the queue*\n\n"+
+				"The bot restarted briefly. We'll connect you as soon as a match appears.",
+			createCancelSearchKeyboard())
+	}
+
+	log.Printf("💝 Restored %d blind pairs and %d waiting users", restoredPairs, restoredWaiting)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- COMMENT REPLIES -----------------
+
+// Replies are comments with a parent_id. Threads are one level deep, so a
+// reply from inside a thread always answers the top-level comment.
+
+// startCommentReply handles comment:reply:<comment_id> from the viewer and
+// puts the user in the regular comment step with the parent set.
+func startCommentReply(commentID int64, cb *tgbotapi.CallbackQuery) {
+	userID := cb.From.ID
+	chatID := cb.Message.Chat.ID
+	if chatID != userID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "🔒 Private chat only"))
+		return
+	}
+
+	parent, err := getComment(commentID)
+	if err != nil || parent.Status != "visible" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Comment not found"))
+		return
+	}
+	if parent.ParentID != 0 {
+		commentID = parent.ParentID
+		if parent, err = getComment(commentID); err != nil || parent.Status != "visible" {
+			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Comment not found"))
+			return
+		}
+	}
+
+	bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+
+	if isBanned(userID) || !allowAction(userID, chatID, ActionComment) {
+		return
+	}
+
+	var channelMessageID sql.NullInt64
+	db.QueryRow("SELECT channel_message_id FROM confessions WHERE id = ?", parent.ConfessionID).Scan(&channelMessageID)
+
+	sessions.SetComment(userID, CommentData{
+		ConfessionID:      parent.ConfessionID,
+		MessageID:         int(channelMessageID.Int64),
+		ParentID:          commentID,
+		UserID:            userID,
+		WaitingForComment: true,
+	})
+
+	sessions.SetKeyboard(chatID, createCancelKeyboard())
+	sendMessageWithKeyboard(chatID,
+		fmt.Sprintf("↩️ *REPLY ANONYMOUSLY*\n──────────────\n\n"+
+			"💬 *Replying to:*\n%s\n\n"+
+			"──────────────\n"+
+			"✨ *Write your reply below* (max %d characters)\n\n"+
+			"🔒 The author is notified without learning who you are.",
+			escapeMarkdown(truncateText(parent.Text, 300)), cfg.CommentMaxLength),
+		createCancelKeyboard())
+}
+
+func replyNotificationsEnabled(userID int64) bool {
+	var enabled sql.NullInt64
+	err := db.QueryRow("SELECT notify_replies FROM users WHERE user_id = ?", userID).Scan(&enabled)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("Error loading reply notification setting:", err)
+	}
+	return !enabled.Valid || enabled.Int64 == 1
+}
+
+func setReplyNotifications(userID int64, enabled bool) error {
+	value := 0
+	if enabled {
+		value = 1
+	}
+	_, err := db.Exec("UPDATE users SET notify_replies = ? WHERE user_id = ?", value, userID)
+	return err
+}
+
+// notifyCommentReply tells the parent comment's author about a visible
+// reply, unless they replied to themselves or turned notifications off.
+func notifyCommentReply(replyID int64) {
+	reply, err := getComment(replyID)
+	if err != nil || reply.ParentID == 0 || reply.Status != "visible" {
+		return
+	}
+	parent, err := getComment(reply.ParentID)
+	if err != nil || parent.UserID == reply.UserID || !replyNotificationsEnabled(parent.UserID) {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(parent.UserID,
+		fmt.Sprintf("↩️ *NEW REPLY*\n──────────────\n\n"+
+			"Someone replied to your comment on Confession #%d.\n\n"+
+			"💬 *Your comment:*\n%s\n\n"+
+			"↩️ *Reply:*\n%s",
+			parent.ConfessionID, escapeMarkdown(truncateText(parent.Text, 200)), escapeMarkdown(reply.Text)))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL("🧵 View thread",
+				fmt.Sprintf("https://t.me/%s?start=thread%d", botUsername, parent.ID)),
+			tgbotapi.NewInlineKeyboardButtonData("🔕 Mute replies", "comment:mute:0"),
+		),
+	)
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("Error sending reply notification:", err)
+	}
+}
+
+// handleReplyMuteCallback handles the button on a reply notification
+func handleReplyMuteCallback(cb *tgbotapi.CallbackQuery) {
+	if err := setReplyNotifications(cb.From.ID, false); err != nil {
+		log.Println("Error muting reply notifications:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	bot.Send(tgbotapi.NewCallback(cb.ID, "🔕 Reply notifications off. Use /replies on to undo."))
+}
+
+// handleRepliesCommand handles /replies [on|off]
+func handleRepliesCommand(userID int64, chatID int64, args string) {
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		if err := setReplyNotifications(userID, true); err != nil {
+			log.Println("Error updating reply notifications:", err)
+			sendMessage(chatID, "❌ *Error updating your settings*")
+			return
+		}
+		sendMessage(chatID, "🔔 *Reply notifications on*\n\nWe'll let you know when someone replies to your comments.")
+
+	case "off":
+		if err := setReplyNotifications(userID, false); err != nil {
+			log.Println("Error updating reply notifications:", err)
+			sendMessage(chatID, "❌ *Error updating your settings*")
+			return
+		}
+		sendMessage(chatID, "🔕 *Reply notifications off*\n\nUse /replies on to turn them back on.")
+
+	default:
+		state := "🔔 On"
+		if !replyNotificationsEnabled(userID) {
+			state = "🔕 Off"
+		}
+		sendMessage(chatID, fmt.Sprintf("↩️ *Reply notifications:* %s\n\nUse `/replies on` or `/replies off` to change.", state))
+	}
+}
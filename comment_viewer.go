@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- COMMENT VIEWER -----------------
+
+// The viewer is a single message that is edited in place as the reader pages
+// through a confession's visible comments, or through the replies to one of
+// them. Buttons carry enough state that every callback can redraw the
+// message from scratch:
+//
+//	comment:page:<confession_id>:<page>:<order>[:<parent_id>]
+//	comment:react:<comment_id>:<reaction>:<page>:<order>
+
+const commentsPerPage = 5
+
+// Comment orders
+const (
+	CommentsNewest = "new"
+	CommentsOldest = "old"
+)
+
+var commentReactions = []string{"👍", "❤️"}
+
+type viewerComment struct {
+	ID        int64
+	Text      string
+	CreatedAt time.Time
+	Reactions []int
+	Replies   int
+}
+
+// renderCommentPage builds the text and buttons for one page of top-level
+// comments, or of the replies to parentID; page is clamped to the available
+// range.
+func renderCommentPage(confessionID int, parentID int64, page int, order string) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	noButtons := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	backRow := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("⬅️ All comments",
+		fmt.Sprintf("comment:page:%d:0:%s", confessionID, CommentsNewest)))
+
+	filter := "parent_id IS NULL"
+	filterArgs := []interface{}{confessionID}
+	var parent commentInfo
+	if parentID != 0 {
+		var err error
+		parent, err = getComment(parentID)
+		if err != nil || parent.Status != "visible" {
+			return "💭 *This comment is no longer available*", tgbotapi.NewInlineKeyboardMarkup(backRow), nil
+		}
+		filter = "parent_id = ?"
+		filterArgs = append(filterArgs, parentID)
+	}
+
+	var total int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM confession_comments
+		WHERE confession_id = ? AND status = 'visible' AND `+filter, filterArgs...).Scan(&total)
+	if err != nil {
+		return "", noButtons, err
+	}
+
+	if total == 0 && parentID == 0 {
+		return fmt.Sprintf("💭 *No comments yet for Confession #%d*\n\nBe the first to comment! 🤫", confessionID),
+			noButtons, nil
+	}
+
+	pages := (total + commentsPerPage - 1) / commentsPerPage
+	if pages == 0 {
+		pages = 1
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	direction := "DESC"
+	orderText := "Newest first"
+	if order == CommentsOldest {
+		direction = "ASC"
+		orderText = "Oldest first"
+	}
+
+	rows, err := db.Query(`
+		SELECT id, COALESCE(text, ''), created_at,
+		       (SELECT COUNT(*) FROM confession_comments r WHERE r.parent_id = c.id AND r.status = 'visible')
+		FROM confession_comments c
+		WHERE confession_id = ? AND status = 'visible' AND `+filter+`
+		ORDER BY created_at `+direction+`, id `+direction+`
+		LIMIT ? OFFSET ?`, append(filterArgs, commentsPerPage, page*commentsPerPage)...)
+	if err != nil {
+		return "", noButtons, err
+	}
+
+	var comments []viewerComment
+	for rows.Next() {
+		var c viewerComment
+		if err := rows.Scan(&c.ID, &c.Text, &c.CreatedAt, &c.Replies); err != nil {
+			log.Println("Error scanning comment:", err)
+			continue
+		}
+		comments = append(comments, c)
+	}
+	rows.Close()
+
+	for i := range comments {
+		comments[i].Reactions = commentReactionCounts(comments[i].ID)
+	}
+
+	// Page and order buttons stay inside the thread when there is one
+	pageData := func(page int, order string) string {
+		data := fmt.Sprintf("comment:page:%d:%d:%s", confessionID, page, order)
+		if parentID != 0 {
+			data += fmt.Sprintf(":%d", parentID)
+		}
+		return data
+	}
+
+	var text string
+	if parentID == 0 {
+		text = fmt.Sprintf("📊 *Comments on Confession #%d*\n"+
+			"📄 Page %d/%d · %d total · %s\n──────────────\n\n",
+			confessionID, page+1, pages, total, orderText)
+	} else {
+		text = fmt.Sprintf("🧵 *Thread on Confession #%d*\n\n"+
+			"💬 *Anonymous:*\n%s\n\n"+
+			"📄 Page %d/%d · %d replies · %s\n──────────────\n\n",
+			confessionID, escapeMarkdown(parent.Text), page+1, pages, total, orderText)
+		if total == 0 {
+			text += "No replies yet. Be the first! 🤫\n"
+		}
+	}
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i, c := range comments {
+		number := page*commentsPerPage + i + 1
+
+		var counts []string
+		var row []tgbotapi.InlineKeyboardButton
+		for r, emoji := range commentReactions {
+			counts = append(counts, fmt.Sprintf("%s %d", emoji, c.Reactions[r]))
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("%d · %s %d", number, emoji, c.Reactions[r]),
+				fmt.Sprintf("comment:react:%d:%d:%d:%s", c.ID, r, page, order)))
+		}
+		if parentID == 0 {
+			counts = append(counts, fmt.Sprintf("💬 %d", c.Replies))
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("%d · 💬 %d", number, c.Replies),
+				fmt.Sprintf("comment:page:%d:0:%s:%d", confessionID, CommentsOldest, c.ID)))
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%d · 🚩", number), fmt.Sprintf("comment:report:%d", c.ID)))
+		keyboard = append(keyboard, row)
+
+		label := "Anonymous"
+		if parentID != 0 {
+			label = "Reply"
+		}
+		text += fmt.Sprintf("💬 *%d. %s* (%s):\n%s\n%s\n──────────────\n",
+			number, label, c.CreatedAt.Format("Jan 2, 3:04 PM"), escapeMarkdown(c.Text), strings.Join(counts, " · "))
+	}
+
+	if parentID == 0 {
+		text += "\n💬 *Want to add a comment?*\n" +
+			"Click the '💬 Comment' button in the channel!\n" +
+			"Tap a 💬 number below to read and reply to a thread."
+	} else {
+		text += "\n↩️ *Tap Reply to answer anonymously.*"
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️ Prev", pageData(page-1, order)))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("Next ▶️", pageData(page+1, order)))
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+
+	toggle := tgbotapi.NewInlineKeyboardButtonData("🔃 Oldest first", pageData(0, CommentsOldest))
+	if order == CommentsOldest {
+		toggle = tgbotapi.NewInlineKeyboardButtonData("🔃 Newest first", pageData(0, CommentsNewest))
+	}
+	if total > 1 {
+		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{toggle})
+	}
+
+	if parentID != 0 {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Reply", fmt.Sprintf("comment:reply:%d", parentID))))
+		keyboard = append(keyboard, backRow)
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(keyboard...), nil
+}
+
+func commentReactionCounts(commentID int64) []int {
+	counts := make([]int, len(commentReactions))
+	for i, emoji := range commentReactions {
+		db.QueryRow(`
+			SELECT COUNT(*) FROM comment_reactions
+			WHERE comment_id = ? AND emoji = ?`, commentID, emoji).Scan(&counts[i])
+	}
+	return counts
+}
+
+// sendCommentViewer opens the viewer on the first page of a confession's
+// comments, or of one comment's replies when parentID is set
+func sendCommentViewer(chatID int64, confessionID int, parentID int64, order string) {
+	text, keyboard, err := renderCommentPage(confessionID, parentID, 0, order)
+	if err != nil {
+		log.Println("Error loading comments:", err)
+		sendMessage(chatID, "❌ *Error loading comments*")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	if len(keyboard.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	bot.Send(msg)
+}
+
+// sendCommentThread opens the thread a comment belongs to
+func sendCommentThread(chatID int64, commentID int64) {
+	c, err := getComment(commentID)
+	if err != nil {
+		sendMessage(chatID, "❌ *Comment not found*")
+		return
+	}
+	if c.ParentID != 0 {
+		commentID = c.ParentID
+	}
+	sendCommentViewer(chatID, c.ConfessionID, commentID, CommentsOldest)
+}
+
+// editCommentViewer redraws the viewer message a callback came from
+func editCommentViewer(cb *tgbotapi.CallbackQuery, confessionID int, parentID int64, page int, order string) {
+	text, keyboard, err := renderCommentPage(confessionID, parentID, page, order)
+	if err != nil {
+		log.Println("Error loading comments:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	editMsg := tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID, text, keyboard)
+	editMsg.ParseMode = "Markdown"
+	// Telegram rejects edits that change nothing; that's fine here
+	bot.Send(editMsg)
+}
+
+// handleCommentPageCallback handles comment:page:<confession_id>:<page>:<order>[:<parent_id>]
+func handleCommentPageCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 5 {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	confessionID, _ := strconv.Atoi(parts[2])
+	page, _ := strconv.Atoi(parts[3])
+	var parentID int64
+	if len(parts) > 5 {
+		parentID, _ = strconv.ParseInt(parts[5], 10, 64)
+	}
+
+	editCommentViewer(cb, confessionID, parentID, page, parts[4])
+	bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+}
+
+// handleCommentReactCallback toggles a reaction and redraws the same page
+func handleCommentReactCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 6 {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	commentID, _ := strconv.ParseInt(parts[2], 10, 64)
+	reaction, err := strconv.Atoi(parts[3])
+	if err != nil || reaction < 0 || reaction >= len(commentReactions) {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	page, _ := strconv.Atoi(parts[4])
+	emoji := commentReactions[reaction]
+	userID := cb.From.ID
+
+	c, err := getComment(commentID)
+	if err != nil || c.Status != "visible" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Comment not found"))
+		return
+	}
+
+	result, err := db.Exec(`
+		DELETE FROM comment_reactions
+		WHERE comment_id = ? AND user_id = ? AND emoji = ?`, commentID, userID, emoji)
+	if err != nil {
+		log.Println("Error removing comment reaction:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		_, err = db.Exec(`
+			INSERT INTO comment_reactions (comment_id, user_id, emoji)
+			VALUES (?, ?, ?)`, commentID, userID, emoji)
+		if err != nil {
+			log.Println("Error saving comment reaction:", err)
+			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+			return
+		}
+	}
+
+	// Replies are redrawn inside their thread
+	editCommentViewer(cb, c.ConfessionID, c.ParentID, page, parts[5])
+	bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Reaction updated"))
+}
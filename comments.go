@@ -0,0 +1,330 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- COMMENT MODERATION -----------------
+
+// Comment status is pending (waiting for approval when cfg.CommentApproval
+// is on), visible, hidden (reversible), deleted, or archived when the author
+// withdraws the confession. Only visible comments are shown or counted on the
+// channel post.
+
+func visibleCommentCount(confessionID int) int {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM confession_comments
+		WHERE confession_id = ? AND status = 'visible'`, confessionID).Scan(&count)
+	if err != nil {
+		log.Println("Error counting comments:", err)
+	}
+	return count
+}
+
+// refreshCommentCount updates the channel post after a comment changes status
+func refreshCommentCount(confessionID int) {
+	var channelMessageID sql.NullInt64
+	db.QueryRow("SELECT channel_message_id FROM confessions WHERE id = ?", confessionID).Scan(&channelMessageID)
+	if !channelMessageID.Valid || channelMessageID.Int64 == 0 {
+		return
+	}
+	updateCommentCount(confessionID, int(channelMessageID.Int64))
+}
+
+type commentInfo struct {
+	ID           int64
+	ConfessionID int
+	ParentID     int64
+	UserID       int64
+	Text         string
+	Status       string
+}
+
+func getComment(commentID int64) (commentInfo, error) {
+	c := commentInfo{ID: commentID}
+	err := db.QueryRow(`
+		SELECT confession_id, COALESCE(parent_id, 0), user_id, COALESCE(text, ''), status
+		FROM confession_comments WHERE id = ?`, commentID).Scan(
+		&c.ConfessionID, &c.ParentID, &c.UserID, &c.Text, &c.Status)
+	return c, err
+}
+
+func commentStatusText(status string) string {
+	switch status {
+	case "pending":
+		return "⏳ Awaiting approval"
+	case "visible":
+		return "✅ Visible"
+	case "hidden":
+		return "🙈 Hidden"
+	case "deleted":
+		return "🗑️ Deleted"
+	case "archived":
+		return "📦 Archived"
+	}
+	return status
+}
+
+func commentAdminText(c commentInfo, title string, note string) string {
+	var reports int
+	db.QueryRow("SELECT COUNT(*) FROM comment_reports WHERE comment_id = ?", c.ID).Scan(&reports)
+
+	confession := fmt.Sprintf("#%d", c.ConfessionID)
+	if c.ParentID != 0 {
+		confession += fmt.Sprintf(", reply to comment #%d", c.ParentID)
+	}
+
+	return fmt.Sprintf("%s · Comment #%d\n──────────────\n\n"+
+		"📜 *Confession:* %s\n"+
+		"💬 *Comment:*\n%s\n\n"+
+		"%s"+
+		"──────────────\n"+
+		"👤 *Sender ID:* `%d`\n"+
+		"🚩 *Reports:* %d\n"+
+		"📊 *Status:* %s",
+		title, c.ID, confession, escapeMarkdown(c.Text), note, c.UserID, reports, commentStatusText(c.Status))
+}
+
+func createCommentAdminKeyboard(commentID int64, status string) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	switch status {
+	case "pending":
+		row = append(row,
+			tgbotapi.NewInlineKeyboardButtonData("✅ Approve", fmt.Sprintf("comment:approve:%d", commentID)),
+			tgbotapi.NewInlineKeyboardButtonData("🙈 Reject", fmt.Sprintf("comment:hide:%d", commentID)))
+	case "visible":
+		row = append(row,
+			tgbotapi.NewInlineKeyboardButtonData("🙈 Hide", fmt.Sprintf("comment:hide:%d", commentID)))
+	case "hidden":
+		row = append(row,
+			tgbotapi.NewInlineKeyboardButtonData("👁️ Show", fmt.Sprintf("comment:show:%d", commentID)))
+	case "deleted":
+		return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	}
+	row = append(row,
+		tgbotapi.NewInlineKeyboardButtonData("🗑️ Delete", fmt.Sprintf("comment:delete:%d", commentID)))
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// sendCommentToAdmins posts a comment for review with the moderation buttons
+func sendCommentToAdmins(commentID int64, title string, note string) {
+	c, err := getComment(commentID)
+	if err != nil {
+		log.Println("Error loading comment:", err)
+		return
+	}
+
+	adminMsg := tgbotapi.NewMessage(adminGroupID, commentAdminText(c, title, note))
+	adminMsg.ParseMode = "Markdown"
+	adminMsg.ReplyMarkup = createCommentAdminKeyboard(commentID, c.Status)
+
+	sent, err := bot.Send(adminMsg)
+	if err != nil {
+		log.Println("Error sending comment to admins:", err)
+		return
+	}
+	db.Exec("UPDATE confession_comments SET admin_message_id = ? WHERE id = ?", sent.MessageID, commentID)
+}
+
+// setCommentStatus moves a comment to status, optionally only from one of
+// the given statuses, and refreshes the channel count. It reports whether
+// the comment changed.
+func setCommentStatus(commentID int64, status string, from ...string) (bool, error) {
+	query := "UPDATE confession_comments SET status = ? WHERE id = ? AND status != ?"
+	args := []interface{}{status, commentID, status}
+	if len(from) > 0 {
+		query += " AND status IN (?" + strings.Repeat(", ?", len(from)-1) + ")"
+		for _, s := range from {
+			args = append(args, s)
+		}
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return false, nil
+	}
+
+	var confessionID int
+	db.QueryRow("SELECT confession_id FROM confession_comments WHERE id = ?", commentID).Scan(&confessionID)
+	refreshCommentCount(confessionID)
+	return true, nil
+}
+
+func handleCommentCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 3 || cb.Message == nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	action := parts[1]
+	switch action {
+	case "page":
+		handleCommentPageCallback(parts, cb)
+		return
+	case "react":
+		handleCommentReactCallback(parts, cb)
+		return
+	}
+
+	commentID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	switch action {
+	case "report":
+		handleCommentReport(commentID, cb)
+		return
+	case "reply":
+		startCommentReply(commentID, cb)
+		return
+	case "mute":
+		handleReplyMuteCallback(cb)
+		return
+	}
+
+	// Everything else is a moderation decision
+	if cb.Message.Chat.ID != adminGroupID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	before, err := getComment(commentID)
+	if err != nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Comment not found"))
+		return
+	}
+
+	var changed bool
+	switch action {
+	case "approve":
+		changed, err = setCommentStatus(commentID, "visible", "pending")
+	case "hide":
+		changed, err = setCommentStatus(commentID, "hidden", "pending", "visible")
+	case "show":
+		changed, err = setCommentStatus(commentID, "visible", "hidden")
+	case "delete":
+		changed, err = setCommentStatus(commentID, "deleted")
+	default:
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown action"))
+		return
+	}
+	if err != nil {
+		log.Println("Error updating comment:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	if !changed {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already handled"))
+		return
+	}
+
+	c, err := getComment(commentID)
+	if err != nil {
+		log.Println("Error loading comment:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	editMsg := tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID,
+		commentAdminText(c, "💬 *COMMENT*", ""), createCommentAdminKeyboard(commentID, c.Status))
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+
+	// Let the author know how a held comment was decided
+	if before.Status == "pending" {
+		if c.Status == "visible" {
+			sendMessage(c.UserID, fmt.Sprintf("✅ *Comment Approved*\n\nYour comment on Confession #%d is now visible.", c.ConfessionID))
+			onCommentVisible(commentID)
+		} else {
+			sendMessage(c.UserID, fmt.Sprintf("❌ *Comment Not Approved*\n\nYour comment on Confession #%d wasn't published.", c.ConfessionID))
+		}
+	}
+
+	bot.Send(tgbotapi.NewCallback(cb.ID, "✅ "+commentStatusText(c.Status)))
+}
+
+// handleCommentReport records a user's report. The first report brings the
+// comment to the admins; enough reports hide it until they decide.
+func handleCommentReport(commentID int64, cb *tgbotapi.CallbackQuery) {
+	reporterID := cb.From.ID
+
+	c, err := getComment(commentID)
+	if err != nil || c.Status != "visible" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Comment not found"))
+		return
+	}
+	if c.UserID == reporterID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ That's your own comment"))
+		return
+	}
+
+	result, err := db.Exec(`
+		INSERT OR IGNORE INTO comment_reports (comment_id, reporter_id)
+		VALUES (?, ?)`, commentID, reporterID)
+	if err != nil {
+		log.Println("Error saving comment report:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ You already reported this comment"))
+		return
+	}
+
+	var reports int
+	db.QueryRow("SELECT COUNT(*) FROM comment_reports WHERE comment_id = ?", commentID).Scan(&reports)
+
+	if reports >= cfg.CommentHideThreshold {
+		if _, err := setCommentStatus(commentID, "hidden", "visible"); err != nil {
+			log.Println("Error hiding reported comment:", err)
+		}
+		sendCommentToAdmins(commentID, "🙈 *COMMENT AUTO-HIDDEN*",
+			fmt.Sprintf("⚠️ Hidden after %d reports. Show it again if it's fine.\n\n", reports))
+	} else if reports == 1 {
+		sendCommentToAdmins(commentID, "🚩 *REPORTED COMMENT*", "")
+	}
+
+	bot.Send(tgbotapi.NewCallback(cb.ID, "🚩 Reported. Thank you!"))
+}
+
+// adminSetCommentStatus backs the /hidecomment, /showcomment and
+// /deletecomment commands.
+func adminSetCommentStatus(chatID int64, args []string, status string, usage string) {
+	if len(args) < 1 {
+		sendMessage(chatID, fmt.Sprintf("ℹ️ *Usage:* `%s`", usage))
+		return
+	}
+	commentID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		sendMessage(chatID, "❌ *Invalid comment ID*")
+		return
+	}
+	if _, err := getComment(commentID); err != nil {
+		sendMessage(chatID, fmt.Sprintf("❌ *Unknown comment* #%d", commentID))
+		return
+	}
+
+	changed, err := setCommentStatus(commentID, status)
+	if err != nil {
+		log.Println("Error updating comment:", err)
+		sendMessage(chatID, "❌ *Error updating comment*")
+		return
+	}
+	if !changed {
+		sendMessage(chatID, fmt.Sprintf("ℹ️ *Comment #%d is already %s*", commentID, status))
+		return
+	}
+	sendMessage(chatID, fmt.Sprintf("✅ *Comment #%d:* %s", commentID, commentStatusText(status)))
+}
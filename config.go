@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------- CONFIGURATION -----------------
+
+// Duration wraps time.Duration so config files can use values like "10m".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10m\": %v", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+type Config struct {
+	BotToken     string `json:"bot_token"`
+	AdminGroupID int64  `json:"admin_group_id"`
+	ChannelID    int64  `json:"channel_id"`
+	DBPath       string `json:"db_path"`
+	Debug        bool   `json:"debug"`
+
+	CleanupInterval Duration `json:"cleanup_interval"`
+	StateTimeout    Duration `json:"state_timeout"`
+	CommentTimeout  Duration `json:"comment_timeout"`
+
+	ConfessionMinLength int `json:"confession_min_length"`
+	ConfessionMaxLength int `json:"confession_max_length"`
+	CommentMaxLength    int `json:"comment_max_length"`
+
+	VoiceConfessionMaxSeconds int `json:"voice_confession_max_seconds"`
+	BlindVoiceMaxSeconds      int `json:"blind_voice_max_seconds"`
+
+	ReportBanThreshold int      `json:"report_ban_threshold"`
+	TempBanDuration    Duration `json:"temp_ban_duration"`
+
+	// OppositeGenderOnly restricts blind connections to opposite-gender pairs
+	// on top of each user's own preference
+	OppositeGenderOnly bool `json:"opposite_gender_only"`
+
+	UpdateWorkers   int      `json:"update_workers"`
+	UpdateQueueSize int      `json:"update_queue_size"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+
+	VoiceWorkers      int      `json:"voice_workers"`
+	VoiceMaxAttempts  int      `json:"voice_max_attempts"`
+	VoiceRetryBackoff Duration `json:"voice_retry_backoff"`
+	VoicePollInterval Duration `json:"voice_poll_interval"`
+
+	// RateLimits maps an action (admin_contact, confession, comment) to its
+	// sliding window
+	RateLimits map[string]RateLimit `json:"rate_limits"`
+
+	// MaxPendingConfessions caps the whole approval queue
+	MaxPendingConfessions int `json:"max_pending_confessions"`
+
+	Moderation ModerationConfig `json:"moderation"`
+
+	// CommentApproval holds new comments for admin review before they count;
+	// CommentHideThreshold hides a comment once that many users report it
+	CommentApproval      bool `json:"comment_approval"`
+	CommentHideThreshold int  `json:"comment_hide_threshold"`
+
+	// Authors who opt in get activity digests at most this often
+	NotificationDigestInterval Duration `json:"notification_digest_interval"`
+	ReactionMilestones         []int    `json:"reaction_milestones"`
+
+	// Approved confessions are posted one per PublishInterval between
+	// PublishWindowStart and PublishWindowEnd (HH:MM, server local time).
+	// A zero interval posts them as soon as they're approved. A confession
+	// that fails to post PublishMaxAttempts times is taken out of the queue.
+	PublishInterval    Duration `json:"publish_interval"`
+	PublishWindowStart string   `json:"publish_window_start"`
+	PublishWindowEnd   string   `json:"publish_window_end"`
+	PublishMaxAttempts int      `json:"publish_max_attempts"`
+
+	// Window bounds in minutes after midnight, set by validate
+	publishStart int
+	publishEnd   int
+}
+
+var cfg *Config
+
+func defaultConfig() *Config {
+	return &Config{
+		DBPath:                    "confess.db",
+		CleanupInterval:           Duration{10 * time.Minute},
+		StateTimeout:              Duration{30 * time.Minute},
+		CommentTimeout:            Duration{10 * time.Minute},
+		ConfessionMinLength:       10,
+		ConfessionMaxLength:       2000,
+		CommentMaxLength:          500,
+		VoiceConfessionMaxSeconds: 120,
+		BlindVoiceMaxSeconds:      60,
+		ReportBanThreshold:        3,
+		TempBanDuration:           Duration{7 * 24 * time.Hour},
+		UpdateWorkers:             8,
+		UpdateQueueSize:           100,
+		ShutdownTimeout:           Duration{30 * time.Second},
+		VoiceWorkers:              2,
+		VoiceMaxAttempts:          3,
+		VoiceRetryBackoff:         Duration{30 * time.Second},
+		VoicePollInterval:         Duration{5 * time.Second},
+		RateLimits: map[string]RateLimit{
+			ActionAdminContact:     {Max: 1, Window: Duration{7 * 24 * time.Hour}},
+			ActionConfession:       {Max: 5, Window: Duration{24 * time.Hour}},
+			ActionConfessionHourly: {Max: 2, Window: Duration{time.Hour}},
+			ActionVoiceConfession:  {Max: 2, Window: Duration{24 * time.Hour}},
+			ActionComment:          {Max: 10, Window: Duration{10 * time.Minute}},
+		},
+		MaxPendingConfessions:      100,
+		Moderation:                 defaultModerationConfig(),
+		CommentHideThreshold:       3,
+		NotificationDigestInterval: Duration{time.Hour},
+		ReactionMilestones:         []int{10, 50, 100},
+		PublishInterval:            Duration{20 * time.Minute},
+		PublishWindowStart:         "08:00",
+		PublishWindowEnd:           "23:00",
+		PublishMaxAttempts:         3,
+	}
+}
+
+// loadConfig builds the configuration from defaults, then the optional JSON
+// file at path, then environment variables. Later sources win.
+func loadConfig(path string) (*Config, error) {
+	c := defaultConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+		}
+	}
+
+	if err := applyEnv(c); err != nil {
+		return nil, err
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func applyEnv(c *Config) error {
+	stringVars := map[string]*string{
+		"BOT_TOKEN":            &c.BotToken,
+		"DB_PATH":              &c.DBPath,
+		"PUBLISH_WINDOW_START": &c.PublishWindowStart,
+		"PUBLISH_WINDOW_END":   &c.PublishWindowEnd,
+	}
+	for name, target := range stringVars {
+		if v, ok := os.LookupEnv(name); ok {
+			*target = v
+		}
+	}
+
+	int64Vars := map[string]*int64{
+		"ADMIN_GROUP_ID": &c.AdminGroupID,
+		"CHANNEL_ID":     &c.ChannelID,
+	}
+	for name, target := range int64Vars {
+		if v, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", name, err)
+			}
+			*target = parsed
+		}
+	}
+
+	intVars := map[string]*int{
+		"CONFESSION_MIN_LENGTH":        &c.ConfessionMinLength,
+		"CONFESSION_MAX_LENGTH":        &c.ConfessionMaxLength,
+		"COMMENT_MAX_LENGTH":           &c.CommentMaxLength,
+		"VOICE_CONFESSION_MAX_SECONDS": &c.VoiceConfessionMaxSeconds,
+		"BLIND_VOICE_MAX_SECONDS":      &c.BlindVoiceMaxSeconds,
+		"REPORT_BAN_THRESHOLD":         &c.ReportBanThreshold,
+		"UPDATE_WORKERS":               &c.UpdateWorkers,
+		"UPDATE_QUEUE_SIZE":            &c.UpdateQueueSize,
+		"VOICE_WORKERS":                &c.VoiceWorkers,
+		"VOICE_MAX_ATTEMPTS":           &c.VoiceMaxAttempts,
+		"MAX_PENDING_CONFESSIONS":      &c.MaxPendingConfessions,
+		"COMMENT_HIDE_THRESHOLD":       &c.CommentHideThreshold,
+		"PUBLISH_MAX_ATTEMPTS":         &c.PublishMaxAttempts,
+	}
+	for name, target := range intVars {
+		if v, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", name, err)
+			}
+			*target = parsed
+		}
+	}
+
+	boolVars := map[string]*bool{
+		"BOT_DEBUG":            &c.Debug,
+		"OPPOSITE_GENDER_ONLY": &c.OppositeGenderOnly,
+		"COMMENT_APPROVAL":     &c.CommentApproval,
+	}
+	for name, target := range boolVars {
+		if v, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", name, err)
+			}
+			*target = parsed
+		}
+	}
+
+	durationVars := map[string]*Duration{
+		"CLEANUP_INTERVAL":             &c.CleanupInterval,
+		"STATE_TIMEOUT":                &c.StateTimeout,
+		"COMMENT_TIMEOUT":              &c.CommentTimeout,
+		"SHUTDOWN_TIMEOUT":             &c.ShutdownTimeout,
+		"VOICE_RETRY_BACKOFF":          &c.VoiceRetryBackoff,
+		"VOICE_POLL_INTERVAL":          &c.VoicePollInterval,
+		"TEMP_BAN_DURATION":            &c.TempBanDuration,
+		"NOTIFICATION_DIGEST_INTERVAL": &c.NotificationDigestInterval,
+		"PUBLISH_INTERVAL":             &c.PublishInterval,
+	}
+	for name, target := range durationVars {
+		if v, ok := os.LookupEnv(name); ok {
+			parsed, err := time.ParseDuration(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", name, err)
+			}
+			target.Duration = parsed
+		}
+	}
+
+	// MODERATION_BANNED_WORDS=word1,word2 replaces the configured list
+	if v, ok := os.LookupEnv("MODERATION_BANNED_WORDS"); ok {
+		c.Moderation.BannedWords = strings.Split(v, ",")
+	}
+
+	// RATE_LIMIT_CONFESSION=5/24h and so on, one per action
+	for action := range c.RateLimits {
+		name := "RATE_LIMIT_" + strings.ToUpper(action)
+		if v, ok := os.LookupEnv(name); ok {
+			maxText, windowText, found := strings.Cut(strings.TrimSpace(v), "/")
+			maxCount, err := strconv.Atoi(maxText)
+			if !found || err != nil {
+				return fmt.Errorf("invalid %s: expected max/window like 5/24h", name)
+			}
+			window, err := time.ParseDuration(windowText)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", name, err)
+			}
+			c.RateLimits[action] = RateLimit{Max: maxCount, Window: Duration{window}}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validate() error {
+	var problems []string
+
+	if c.BotToken == "" {
+		problems = append(problems, "bot token is required (BOT_TOKEN)")
+	}
+	if c.AdminGroupID == 0 {
+		problems = append(problems, "admin group ID is required (ADMIN_GROUP_ID)")
+	}
+	if c.ChannelID == 0 {
+		problems = append(problems, "channel ID is required (CHANNEL_ID)")
+	}
+	if c.DBPath == "" {
+		problems = append(problems, "database path must not be empty (DB_PATH)")
+	}
+	if c.CleanupInterval.Duration <= 0 || c.StateTimeout.Duration <= 0 || c.CommentTimeout.Duration <= 0 {
+		problems = append(problems, "cleanup interval and timeouts must be positive")
+	}
+	if c.ConfessionMinLength < 1 || c.ConfessionMaxLength < c.ConfessionMinLength {
+		problems = append(problems, "confession length limits must satisfy 1 <= min <= max")
+	}
+	if c.CommentMaxLength < 1 {
+		problems = append(problems, "comment max length must be positive")
+	}
+	if c.VoiceConfessionMaxSeconds < 1 || c.BlindVoiceMaxSeconds < 1 {
+		problems = append(problems, "voice duration limits must be positive")
+	}
+	if c.ReportBanThreshold < 1 {
+		problems = append(problems, "report ban threshold must be at least 1")
+	}
+	if c.TempBanDuration.Duration <= 0 {
+		problems = append(problems, "temporary ban duration must be positive")
+	}
+	if c.UpdateWorkers < 1 || c.UpdateQueueSize < 1 {
+		problems = append(problems, "update workers and queue size must be positive")
+	}
+	if c.ShutdownTimeout.Duration <= 0 {
+		problems = append(problems, "shutdown timeout must be positive")
+	}
+	if c.VoiceWorkers < 1 || c.VoiceMaxAttempts < 1 {
+		problems = append(problems, "voice workers and max attempts must be positive")
+	}
+	if c.VoiceRetryBackoff.Duration <= 0 || c.VoicePollInterval.Duration <= 0 {
+		problems = append(problems, "voice retry backoff and poll interval must be positive")
+	}
+
+	if c.MaxPendingConfessions < 1 {
+		problems = append(problems, "max pending confessions must be positive")
+	}
+	if c.CommentHideThreshold < 1 {
+		problems = append(problems, "comment hide threshold must be at least 1")
+	}
+	if c.NotificationDigestInterval.Duration <= 0 {
+		problems = append(problems, "notification digest interval must be positive")
+	}
+	for _, milestone := range c.ReactionMilestones {
+		if milestone < 1 {
+			problems = append(problems, "reaction milestones must be positive")
+			break
+		}
+	}
+	if c.PublishInterval.Duration < 0 {
+		problems = append(problems, "publish interval must not be negative")
+	}
+	if c.PublishMaxAttempts < 1 {
+		problems = append(problems, "publish max attempts must be positive")
+	}
+	var err error
+	if c.publishStart, err = parseClock(c.PublishWindowStart); err != nil {
+		problems = append(problems, "publish window start: "+err.Error())
+	}
+	if c.publishEnd, err = parseClock(c.PublishWindowEnd); err != nil {
+		problems = append(problems, "publish window end: "+err.Error())
+	}
+	for action, limit := range c.RateLimits {
+		if limit.Max < 1 || limit.Window.Duration <= 0 {
+			problems = append(problems, fmt.Sprintf("rate limit %q needs a positive max and window", action))
+		}
+	}
+	if err := c.Moderation.compile(); err != nil {
+		problems = append(problems, "moderation: "+err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// parseClock turns "HH:MM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// dsn returns the SQLite connection string for the configured database file.
+func (c *Config) dsn() string {
+	return c.DBPath + "?_busy_timeout=5000&_journal_mode=WAL"
+}
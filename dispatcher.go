@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- UPDATE DISPATCHER -----------------
+
+// Dispatcher fans updates out to a fixed pool of workers. Every update from
+// the same user lands on the same worker, so one user's updates are handled
+// in order while different users are handled in parallel. Each worker has a
+// bounded queue; Dispatch blocks when that queue is full.
+type Dispatcher struct {
+	queues []chan tgbotapi.Update
+	handle func(tgbotapi.Update)
+	wg     sync.WaitGroup
+}
+
+func NewDispatcher(workers int, queueSize int, handle func(tgbotapi.Update)) *Dispatcher {
+	d := &Dispatcher{
+		queues: make([]chan tgbotapi.Update, workers),
+		handle: handle,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+	}
+	return d
+}
+
+func (d *Dispatcher) Start() {
+	for i, queue := range d.queues {
+		d.wg.Add(1)
+		go d.work(i, queue)
+	}
+}
+
+func (d *Dispatcher) Dispatch(update tgbotapi.Update) {
+	key := updateKey(update)
+	if key < 0 {
+		key = -key
+	}
+	d.queues[key%int64(len(d.queues))] <- update
+}
+
+// Stop closes the worker queues and waits up to timeout for queued updates to
+// drain. It reports whether every worker finished in time.
+func (d *Dispatcher) Stop(timeout time.Duration) bool {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (d *Dispatcher) work(id int, queue chan tgbotapi.Update) {
+	defer d.wg.Done()
+
+	for update := range queue {
+		d.safeHandle(id, update)
+	}
+}
+
+func (d *Dispatcher) safeHandle(id int, update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Worker %d recovered from panic on update %d: %v\n%s", id, update.UpdateID, r, debug.Stack())
+		}
+	}()
+	d.handle(update)
+}
+
+// updateKey picks the ordering key for an update: the sending user when there
+// is one, otherwise the chat.
+func updateKey(update tgbotapi.Update) int64 {
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	return 0
+}
+
+func handleUpdate(update tgbotapi.Update) {
+	if update.Message != nil {
+		handleMessage(update.Message)
+	}
+	if update.CallbackQuery != nil {
+		handleCallback(update.CallbackQuery)
+	}
+}
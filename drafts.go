@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- CONFESSION DRAFTS -----------------
+
+// A text confession is kept as a draft until the author submits it. Each
+// user has at most one draft; sending new text while writing a confession
+// replaces it. Drafts live in the database so they survive restarts.
+// Every save gets a new revision, and preview buttons carry the revision
+// they were shown for, so an older preview can't act on newer text.
+
+// draftRetention is how long an untouched draft is kept
+const draftRetention = "-30 days"
+
+func getDraft(userID int64) (string, int64, bool) {
+	var text string
+	var revision int64
+	err := db.QueryRow("SELECT text, revision FROM confession_drafts WHERE user_id = ?", userID).Scan(&text, &revision)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("Error loading draft:", err)
+		}
+		return "", 0, false
+	}
+	return text, revision, true
+}
+
+// saveDraft stores the draft and returns its new revision. Revisions are
+// timestamps so they stay unique even after a draft is deleted and rewritten.
+func saveDraft(userID int64, text string) (int64, error) {
+	revision := time.Now().UnixNano()
+	_, err := db.Exec(`
+		INSERT INTO confession_drafts (user_id, text, revision, updated_at)
+		VALUES (?, ?, ?, datetime('now'))
+		ON CONFLICT(user_id) DO UPDATE SET
+			text = excluded.text, revision = excluded.revision, updated_at = excluded.updated_at`,
+		userID, text, revision)
+	return revision, err
+}
+
+func deleteDraft(userID int64) {
+	if _, err := db.Exec("DELETE FROM confession_drafts WHERE user_id = ?", userID); err != nil {
+		log.Println("Error deleting draft:", err)
+	}
+}
+
+func cleanupOldDrafts() {
+	db.Exec("DELETE FROM confession_drafts WHERE updated_at < datetime('now', ?)", draftRetention)
+}
+
+func createDraftKeyboard(revision int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Edit", fmt.Sprintf("draft:edit:%d", revision)),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Submit", fmt.Sprintf("draft:submit:%d", revision)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑️ Discard", fmt.Sprintf("draft:discard:%d", revision)),
+		),
+	)
+}
+
+// sendDraftPreview shows the draft exactly as the channel post will look
+func sendDraftPreview(chatID int64, text string, revision int64) {
+	sendMessage(chatID, "👁️ *Preview*\n\nThis is how your confession will appear in the channel:")
+
+	msg := tgbotapi.NewMessage(chatID, createFrostedMirrorStyle(text))
+	msg.ParseMode = "Markdown"
+	msg.DisableWebPagePreview = true
+	msg.ReplyMarkup = createDraftKeyboard(revision)
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("Error sending draft preview:", err)
+		sendMessage(chatID, "❌ *Preview failed*\n\n"+
+			"Your draft is saved, but we couldn't show it. Press 📝 Text Confession to try again.")
+	}
+}
+
+// handleDraftCallback handles draft:edit|submit|discard:<revision> from the
+// preview
+func handleDraftCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 || cb.Message == nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	userID := cb.From.ID
+	chatID := cb.Message.Chat.ID
+
+	text, revision, ok := getDraft(userID)
+	if !ok {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "⌛ This draft is gone"))
+		removeInlineKeyboard(cb)
+		return
+	}
+	// Previews from before revisions existed have none
+	if len(parts) < 3 || parts[2] != strconv.FormatInt(revision, 10) {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "⌛ This draft was changed, use the latest preview"))
+		removeInlineKeyboard(cb)
+		return
+	}
+
+	switch parts[1] {
+	case "edit":
+		bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+		removeInlineKeyboard(cb)
+		sessions.SetConfessionType(userID, "text")
+		sessions.SetKeyboard(chatID, createCancelKeyboard())
+		sendMessageWithKeyboard(chatID,
+			"✏️ *Edit Draft*\n──────────────\n\n"+
+				"Send the new version of your confession. It replaces your current draft:\n\n"+
+				escapeMarkdown(text),
+			createCancelKeyboard())
+
+	case "submit":
+		bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+		if submitDraft(userID, chatID, text) {
+			removeInlineKeyboard(cb)
+		}
+
+	case "discard":
+		deleteDraft(userID)
+		removeInlineKeyboard(cb)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "🗑️ Discarded"))
+		sendMessageWithKeyboard(chatID, "🗑️ *Draft discarded*\n\nNothing was sent to the admins.", createMainMenuKeyboard())
+
+	default:
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown action"))
+	}
+}
+
+// submitDraft sends a draft for review and reports whether it was sent
+func submitDraft(userID int64, chatID int64, text string) bool {
+	// Drafts outlive bans, so check again before anything is sent
+	if isBanned(userID) {
+		sendMessage(chatID, "🤫 *Your account has been restricted.*\n\nUse /appeal to ask the admins for a review.")
+		return false
+	}
+
+	// The rules may have changed since the draft was written
+	moderation := moderateText(userID, "confession", text)
+	if hit, rejected := moderation.Rejected(); rejected {
+		sendMessage(chatID, fmt.Sprintf("🛡️ *Not Sent*\n\n"+
+			"Your draft was blocked by our automatic filter.\n\n"+
+			"📋 *Reason:* %s\n\n"+
+			"Use ✏️ Edit to fix it.", moderationReasonText(hit.Check)))
+		return false
+	}
+
+	if !allowConfession(userID, chatID, "text") {
+		return false
+	}
+
+	confessionID, err := saveTextConfession(userID, text)
+	if err != nil {
+		log.Println("Error saving confession:", err)
+		sendMessage(chatID, "❌ *Error*\n\nFailed to save confession. Please try again.")
+		return false
+	}
+	deleteDraft(userID)
+	sessions.ClearConfessionType(userID)
+
+	recordConfession(userID, "text")
+	sendTextToAdmin(int(confessionID), userID, text, moderation)
+	sendConfessionSubmittedMessage(chatID, "text")
+	return true
+}
@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -22,8 +25,8 @@ import (
 var (
 	bot          *tgbotapi.BotAPI
 	db           *sql.DB
-	adminGroupID int64 = -1003206729690
-	channelID    int64 = -1003440301269
+	adminGroupID int64
+	channelID    int64
 )
 
 // User states for conversation flow
@@ -34,14 +37,8 @@ type UserState struct {
 }
 
 var (
-	waitingUser       int64
-	pairs             = make(map[int64]BlindChatPair)
-	reports           = make(map[int64]int)
-	userStates        = make(map[int64]*UserState)
-	confessionWaiting = make(map[int64]string) // userID -> confessionType or empty
-	commentWaiting    = make(map[int64]CommentData)
-	activeKeyboards   = make(map[int64]tgbotapi.ReplyKeyboardMarkup)
-	botUsername       string
+	sessions    = NewSessionStore()
+	botUsername string
 )
 
 // Blind Chat Pair structure
@@ -58,6 +55,7 @@ type CommentData struct {
 	ConfessionText     string
 	UserID             int64
 	Username           string
+	ParentID           int64
 	WaitingForComment  bool
 	IsViewingComments  bool
 }
@@ -68,7 +66,7 @@ type Confession struct {
 	UserID           int64
 	Text             string
 	VoiceID          string
-	Type             string // "text" or "voice"
+	Type             string // "text", "voice" or "photo"
 	Date             time.Time
 	Approved         bool
 	ChannelMessageID int
@@ -101,24 +99,40 @@ type BlindProfile struct {
 func main() {
 	var err error
 
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to optional JSON config file")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err = loadConfig(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	adminGroupID = cfg.AdminGroupID
+	channelID = cfg.ChannelID
+
 	// Initialize bot
-	bot, err = tgbotapi.NewBotAPI("7922496272:AAFXpU02Y4v8P_7ubKhwVKzmHDKa36a_Ie4")
+	bot, err = tgbotapi.NewBotAPI(cfg.BotToken)
 	if err != nil {
 		log.Fatal("Failed to create bot:", err)
 	}
 
-	bot.Debug = true
+	bot.Debug = cfg.Debug
 	botUsername = bot.Self.UserName
 	log.Printf("Authorized as @%s", botUsername)
 
 	// Initialize database
-	db, err = sql.Open("sqlite3", "confess.db?_busy_timeout=5000&_journal_mode=WAL")
+	db, err = sql.Open("sqlite3", cfg.dsn())
 	if err != nil {
 		log.Fatal("Failed to open database:", err)
 	}
 	defer db.Close()
 
 	initDB()
+	restoreBlindChats()
+
+	// Background voice anonymization
+	voiceCtx, stopVoiceWorkers := context.WithCancel(context.Background())
+	startVoiceWorkers(voiceCtx, cfg.VoiceWorkers)
 
 	// Start polling
 	u := tgbotapi.NewUpdate(0)
@@ -129,131 +143,36 @@ func main() {
 
 	// Cleanup routines
 	go cleanupRoutine()
+	go notificationDigestRoutine()
+	go publishRoutine()
+
+	// Stop polling on SIGINT/SIGTERM; the updates channel closes once polling ends
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-stop
+		log.Printf("Received %s, shutting down...", sig)
+		bot.StopReceivingUpdates()
+	}()
+
+	dispatcher := NewDispatcher(cfg.UpdateWorkers, cfg.UpdateQueueSize, handleUpdate)
+	dispatcher.Start()
 
 	for update := range updates {
-		if update.Message != nil {
-			handleMessage(update.Message)
-		}
-		if update.CallbackQuery != nil {
-			handleCallback(update.CallbackQuery)
-		}
+		dispatcher.Dispatch(update)
 	}
+
+	if !dispatcher.Stop(cfg.ShutdownTimeout.Duration) {
+		log.Println("Shutdown timed out with updates still in progress")
+	}
+	stopVoiceWorkers()
+	log.Println("👋 Bot stopped")
 }
 
 // ----------------- DATABASE FUNCTIONS -----------------
 func initDB() {
-	// Drop old tables to ensure clean schema
-	dropQueries := []string{
-		"DROP TABLE IF EXISTS confession_comments;",
-		"DROP TABLE IF EXISTS confession_reactions;",
-		"DROP TABLE IF EXISTS blind_profiles;",
-		"DROP TABLE IF EXISTS reports;",
-		"DROP TABLE IF EXISTS confessions;",
-		"DROP TABLE IF EXISTS users;",
-	}
-
-	for _, q := range dropQueries {
-		if _, err := db.Exec(q); err != nil {
-			log.Println("DB error dropping table:", err)
-		}
-	}
-
-	// Create tables with new schema
-	queries := []string{
-		// Users table with gender
-		`CREATE TABLE IF NOT EXISTS users (
-			user_id INTEGER PRIMARY KEY,
-			username TEXT,
-			first_name TEXT,
-			last_name TEXT,
-			gender TEXT CHECK(gender IN ('male', 'female')),
-			banned INTEGER DEFAULT 0,
-			admin_contact_allowed INTEGER DEFAULT 1,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);`,
-
-		// Confessions table with channel message ID
-		`CREATE TABLE IF NOT EXISTS confessions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER,
-			text TEXT,
-			voice_id TEXT,
-			type TEXT DEFAULT 'text',
-			date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			approved INTEGER DEFAULT 0,
-			posted_at TIMESTAMP,
-			channel_message_id INTEGER,
-			FOREIGN KEY (user_id) REFERENCES users(user_id)
-		);`,
-
-		// Blind profiles table
-		`CREATE TABLE IF NOT EXISTS blind_profiles (
-			user_id INTEGER PRIMARY KEY,
-			gender TEXT CHECK(gender IN ('male', 'female')),
-			age INTEGER CHECK(age >= 18 AND age <= 50),
-			years_on_campus INTEGER CHECK(years_on_campus >= 0),
-			year_of_study TEXT,
-			pref_gender TEXT CHECK(pref_gender IN ('male', 'female', 'both')),
-			pref_age_min INTEGER CHECK(pref_age_min >= 18),
-			pref_age_max INTEGER CHECK(pref_age_max <= 50),
-			profile_set INTEGER DEFAULT 0,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (user_id) REFERENCES users(user_id)
-		);`,
-
-		// Reports table
-		`CREATE TABLE IF NOT EXISTS reports (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			reporter_id INTEGER,
-			reported_id INTEGER,
-			reason TEXT,
-			context TEXT,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (reporter_id) REFERENCES users(user_id),
-			FOREIGN KEY (reported_id) REFERENCES users(user_id)
-		);`,
-
-		// Reactions table
-		`CREATE TABLE IF NOT EXISTS confession_reactions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			confession_id INTEGER,
-			user_id INTEGER,
-			emoji TEXT,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(confession_id, user_id, emoji),
-			FOREIGN KEY (confession_id) REFERENCES confessions(id),
-			FOREIGN KEY (user_id) REFERENCES users(user_id)
-		);`,
-
-		// Comments table
-		`CREATE TABLE IF NOT EXISTS confession_comments (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			confession_id INTEGER,
-			user_id INTEGER,
-			username TEXT,
-			text TEXT,
-			anonymous INTEGER DEFAULT 1,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (confession_id) REFERENCES confessions(id),
-			FOREIGN KEY (user_id) REFERENCES users(user_id)
-		);`,
-
-		// Admin contacts table
-		`CREATE TABLE IF NOT EXISTS admin_contacts (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			user_id INTEGER,
-			message TEXT,
-			status TEXT DEFAULT 'pending',
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (user_id) REFERENCES users(user_id)
-		);`,
-	}
-
-	for _, q := range queries {
-		if _, err := db.Exec(q); err != nil {
-			log.Println("DB error creating table:", err)
-		}
+	if err := runMigrations(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
 	}
 	log.Println("💾 Database initialized with enhanced schema")
 }
@@ -273,17 +192,8 @@ func handleMessage(msg *tgbotapi.Message) {
 		}
 	}
 
-	// Initialize user state if not exists
-	if _, exists := userStates[userID]; !exists {
-		userStates[userID] = &UserState{
-			Step:       "idle",
-			Data:       make(map[string]interface{}),
-			LastActive: time.Now(),
-		}
-	}
-
-	// Update last active
-	userStates[userID].LastActive = time.Now()
+	// Initialize user state if not exists and update last active
+	sessions.Touch(userID)
 
 	// Save user to database
 	saveUser(user)
@@ -294,9 +204,18 @@ func handleMessage(msg *tgbotapi.Message) {
 		return
 	}
 
-	// Check if user is banned
+	// Check if user is banned; the appeal flow is the only thing they can still use
 	if isBanned(userID) {
-		sendMessage(chatID, "🤫 *Your account has been restricted.*\n\nContact admin for appeal.")
+		if msg.IsCommand() && msg.Command() == "appeal" {
+			startAppeal(userID, chatID)
+		} else if sessions.Step(userID) == "appeal" {
+			handleAppealMessage(userID, chatID, msg)
+		} else if until, ok := banExpiry(userID); ok {
+			sendMessage(chatID, fmt.Sprintf("🤫 *Your account is temporarily restricted.*\n\n"+
+				"⏳ *Until:* %s\n\nUse /appeal to ask the admins for a review.", until.Format("Jan 2, 3:04 PM")))
+		} else {
+			sendMessage(chatID, "🤫 *Your account has been restricted.*\n\nUse /appeal to ask the admins for a review.")
+		}
 		return
 	}
 
@@ -307,13 +226,13 @@ func handleMessage(msg *tgbotapi.Message) {
 	}
 
 	// Check if user is waiting to comment
-	if commentData, ok := commentWaiting[userID]; ok && commentData.WaitingForComment {
+	if commentData, ok := sessions.Comment(userID); ok && commentData.WaitingForComment {
 		handleUserComment(userID, chatID, msg)
 		return
 	}
 
 	// Handle state machine
-	if userStates[userID].Step != "idle" {
+	if sessions.Step(userID) != "idle" {
 		handleUserState(userID, chatID, msg)
 		return
 	}
@@ -325,7 +244,7 @@ func handleMessage(msg *tgbotapi.Message) {
 	}
 
 	// Handle confession waiting state
-	if confessionType, ok := confessionWaiting[userID]; ok && confessionType != "" {
+	if confessionType := sessions.ConfessionType(userID); confessionType != "" {
 		handleConfessionContent(userID, chatID, msg, confessionType)
 		return
 	}
@@ -334,7 +253,7 @@ func handleMessage(msg *tgbotapi.Message) {
 	handleButtonMessage(userID, chatID, msg)
 
 	// Handle blind chat messages (only if not a button and not in special state)
-	if partner, ok := pairs[userID]; ok && !isButtonText(msg.Text) {
+	if partner, ok := sessions.Partner(userID); ok && !isButtonText(msg.Text) {
 		handleBlindChatMessage(userID, partner, msg)
 		return
 	}
@@ -347,12 +266,12 @@ func handleButtonMessage(userID int64, chatID int64, msg *tgbotapi.Message) {
 	}
 
 	// Set appropriate keyboard based on context
-	if _, inPair := pairs[userID]; inPair {
+	if sessions.InPair(userID) {
 		// User is in blind chat - use romantic keyboard
-		activeKeyboards[chatID] = createRomanticChatKeyboard()
+		sessions.SetKeyboard(chatID, createRomanticChatKeyboard())
 	} else {
 		// User is not in chat - use main menu
-		activeKeyboards[chatID] = createMainMenuKeyboard()
+		sessions.SetKeyboard(chatID, createMainMenuKeyboard())
 	}
 
 	// Handle button presses
@@ -363,6 +282,9 @@ func handleButtonMessage(userID int64, chatID int64, msg *tgbotapi.Message) {
 	case "🎤 Voice Confession":
 		handleVoiceConfessionButton(userID, chatID)
 
+	case "📸 Photo Confession":
+		handlePhotoConfessionButton(userID, chatID)
+
 	case "💝 Blind Connections":
 		handleBlindDatingCommand(userID, chatID)
 
@@ -379,15 +301,15 @@ func handleButtonMessage(userID int64, chatID int64, msg *tgbotapi.Message) {
 		sendFeedbackMessage(chatID)
 
 	case "❌ Cancel Search":
-		if waitingUser == userID {
-			waitingUser = 0
+		if sessions.CancelWaiting(userID) {
+			deleteQueueEntry(userID)
 			mainMenuKeyboard := createMainMenuKeyboard()
-			activeKeyboards[chatID] = mainMenuKeyboard
+			sessions.SetKeyboard(chatID, mainMenuKeyboard)
 			sendMessageWithKeyboard(chatID,
 				"✅ *Search cancelled*\n\nYou left the waiting queue.",
 				mainMenuKeyboard)
 		} else {
-			activeKeyboards[chatID] = createMainMenuKeyboard()
+			sessions.SetKeyboard(chatID, createMainMenuKeyboard())
 			sendMessageWithKeyboard(chatID,
 				"⚠️ *Not searching*\n\nYou're not currently searching.",
 				createMainMenuKeyboard())
@@ -422,12 +344,12 @@ func handleButtonMessage(userID int64, chatID int64, msg *tgbotapi.Message) {
 		return
 
 	case "1st Year", "2nd Year", "3rd Year", "4th Year", "5th+ Year":
-		if userStates[userID].Step == "profile_year_study" {
+		if sessions.Step(userID) == "profile_year_study" {
 			handleProfileYearStudy(userID, chatID, msg.Text)
 		}
 
 	case "👨 Male Only", "👩 Female Only", "👫 Both Genders":
-		if userStates[userID].Step == "profile_pref_gender" {
+		if sessions.Step(userID) == "profile_pref_gender" {
 			handleProfilePrefGender(userID, chatID, msg.Text)
 		}
 	}
@@ -504,7 +426,13 @@ func handleStartCommand(msg *tgbotapi.Message) {
 			confessionIDStr := strings.TrimPrefix(args, "view")
 			confessionID, err := strconv.Atoi(confessionIDStr)
 			if err == nil {
-				handleViewCommentsDeepLink(userID, chatID, confessionID)
+				sendCommentViewer(chatID, confessionID, 0, CommentsNewest)
+				return
+			}
+		} else if strings.HasPrefix(args, "thread") {
+			commentID, err := strconv.ParseInt(strings.TrimPrefix(args, "thread"), 10, 64)
+			if err == nil {
+				sendCommentThread(chatID, commentID)
 				return
 			}
 		}
@@ -520,10 +448,15 @@ func handleCommand(msg *tgbotapi.Message) {
 	chatType := msg.Chat.Type
 
 	// Set appropriate keyboard based on context
-	if _, inPair := pairs[userID]; inPair {
-		activeKeyboards[chatID] = createRomanticChatKeyboard()
+	if sessions.InPair(userID) {
+		sessions.SetKeyboard(chatID, createRomanticChatKeyboard())
 	} else {
-		activeKeyboards[chatID] = createMainMenuKeyboard()
+		sessions.SetKeyboard(chatID, createMainMenuKeyboard())
+	}
+
+	// Moderation commands only work inside the admin group
+	if chatID == adminGroupID && handleAdminCommand(msg) {
+		return
 	}
 
 	switch msg.Command() {
@@ -565,6 +498,32 @@ func handleCommand(msg *tgbotapi.Message) {
 		}
 		showBlindProfile(userID, chatID)
 
+	case "editprofile":
+		if chatType != "private" {
+			sendMessage(chatID, "🔒 *Private Only*\n\nProfile editing in private only.")
+			return
+		}
+		showProfileEditor(userID, chatID)
+
+	case "deleteprofile":
+		if chatType != "private" {
+			sendMessage(chatID, "🔒 *Private Only*\n\nProfile deletion in private only.")
+			return
+		}
+		confirmProfileDeletion(userID, chatID)
+
+	case "appeal":
+		startAppeal(userID, chatID)
+
+	case "replies":
+		handleRepliesCommand(userID, chatID, msg.CommandArguments())
+
+	case "notifications":
+		handleNotificationsCommand(userID, chatID, msg.CommandArguments())
+
+	case "myconfessions":
+		sendMyConfessions(userID, chatID)
+
 	case "help":
 		sendEnhancedHelpMessage(chatID)
 
@@ -580,25 +539,23 @@ func handleCommand(msg *tgbotapi.Message) {
 }
 
 // ----------------- FIXED VOICE ANONYMIZATION WITH RUBBER BAND -----------------
-func anonymizeVoice(voiceFileID string, gender string) (string, error) {
+// anonymizeVoice runs the Rubber Band pipeline and returns the processed OGG
+// bytes and the pitch factor used. progress is called before each step.
+func anonymizeVoice(voiceFileID string, gender string, progress func(step int, text string)) ([]byte, string, error) {
 	// Get the voice file from Telegram
 	file, err := bot.GetFile(tgbotapi.FileConfig{FileID: voiceFileID})
 	if err != nil {
-		return "", fmt.Errorf("failed to get file: %v", err)
+		return nil, "", fmt.Errorf("failed to get file: %v", err)
 	}
 
 	// Create a temporary directory for this voice processing
 	tempDir, err := os.MkdirTemp(os.TempDir(), "voice_*")
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %v", err)
+		return nil, "", fmt.Errorf("failed to create temp directory: %v", err)
 	}
-	
-	// Clean up temp directory after processing
-	defer func() {
-		// Wait a bit to ensure file is no longer in use
-		time.Sleep(2 * time.Second)
-		os.RemoveAll(tempDir)
-	}()
+
+	// Clean up temp directory after processing; the result is read into memory first
+	defer os.RemoveAll(tempDir)
 
 	// Create distinct file paths
 	inputFile := filepath.Join(tempDir, "input.ogg")
@@ -617,20 +574,20 @@ func anonymizeVoice(voiceFileID string, gender string) (string, error) {
 		log.Printf("Wget failed, trying curl: %v, output: %s", err, string(output))
 		downloadCmd = exec.Command("curl", "-s", "-o", inputFile, fileURL)
 		if output, err := downloadCmd.CombinedOutput(); err != nil {
-			return "", fmt.Errorf("failed to download voice file: %v, output: %s", err, string(output))
+			return nil, "", fmt.Errorf("failed to download voice file: %v, output: %s", err, string(output))
 		}
 	}
 
 	// Verify input file
 	fileInfo, err := os.Stat(inputFile)
 	if err != nil || fileInfo.Size() == 0 {
-		return "", fmt.Errorf("input file invalid or empty: %v, size: %d", err, fileInfo.Size())
+		return nil, "", fmt.Errorf("input file invalid or empty: %v, size: %d", err, fileInfo.Size())
 	}
 	
 	log.Printf("Input file downloaded: %d bytes", fileInfo.Size())
 
 	// STEP 1 — Normalize & convert with FFmpeg (NO pitch, NO tempo)
-	sendMessageWithKeyboard(adminGroupID, "🔄 STEP 1/3: FFmpeg normalization...", createMainMenuKeyboard())
+	progress(1, "Normalizing audio")
 	
 	ffmpegCmd1 := exec.Command("ffmpeg",
 		"-y", "-i", inputFile,
@@ -649,16 +606,16 @@ func anonymizeVoice(voiceFileID string, gender string) (string, error) {
 	if err := ffmpegCmd1.Run(); err != nil {
 		log.Printf("FFmpeg STEP 1 failed: %v", err)
 		log.Printf("FFmpeg stderr: %s", stderr1.String())
-		return "", fmt.Errorf("ffmpeg normalization failed: %v", err)
+		return nil, "", fmt.Errorf("ffmpeg normalization failed: %v", err)
 	}
 	
 	// Verify temp WAV file
 	if _, err := os.Stat(tempWav); err != nil {
-		return "", fmt.Errorf("temp WAV file not created: %v", err)
+		return nil, "", fmt.Errorf("temp WAV file not created: %v", err)
 	}
 
 	// STEP 2 — Gender-aware pitch shift using Rubber Band
-	sendMessageWithKeyboard(adminGroupID, "🎵 STEP 2/3: Rubber Band pitch shifting...", createMainMenuKeyboard())
+	progress(2, "Shifting pitch")
 	
 	// Random pitch factor for natural effect
 	var pitchFactor string
@@ -694,7 +651,7 @@ func anonymizeVoice(voiceFileID string, gender string) (string, error) {
 		log.Printf("Rubber Band stderr: %s", stderr2.String())
 		
 		// Fallback to FFmpeg if Rubber Band not available
-		sendMessageWithKeyboard(adminGroupID, "⚠️ Rubber Band not found, using FFmpeg fallback...", createMainMenuKeyboard())
+		log.Println("Rubber Band not available, using FFmpeg fallback")
 		
 		// Simple FFmpeg fallback that maintains speed
 		if gender == "male" {
@@ -706,7 +663,7 @@ func anonymizeVoice(voiceFileID string, gender string) (string, error) {
 			ffmpegFallback.Stderr = &stderr2
 			ffmpegFallback = exec.CommandContext(ctx2, ffmpegFallback.Path, ffmpegFallback.Args[1:]...)
 			if err := ffmpegFallback.Run(); err != nil {
-				return "", fmt.Errorf("ffmpeg fallback also failed: %v", err)
+				return nil, "", fmt.Errorf("ffmpeg fallback also failed: %v", err)
 			}
 		} else {
 			ffmpegFallback := exec.Command("ffmpeg",
@@ -717,13 +674,13 @@ func anonymizeVoice(voiceFileID string, gender string) (string, error) {
 			ffmpegFallback.Stderr = &stderr2
 			ffmpegFallback = exec.CommandContext(ctx2, ffmpegFallback.Path, ffmpegFallback.Args[1:]...)
 			if err := ffmpegFallback.Run(); err != nil {
-				return "", fmt.Errorf("ffmpeg fallback also failed: %v", err)
+				return nil, "", fmt.Errorf("ffmpeg fallback also failed: %v", err)
 			}
 		}
 	}
 
 	// STEP 3 — Output encoding (Telegram-ready)
-	sendMessageWithKeyboard(adminGroupID, "🎧 STEP 3/3: Encoding to OGG...", createMainMenuKeyboard())
+	progress(3, "Encoding")
 	
 	ffmpegCmd3 := exec.Command("ffmpeg",
 		"-y", "-i", outputWav,
@@ -741,12 +698,12 @@ func anonymizeVoice(voiceFileID string, gender string) (string, error) {
 	if err := ffmpegCmd3.Run(); err != nil {
 		log.Printf("FFmpeg STEP 3 failed: %v", err)
 		log.Printf("FFmpeg stderr: %s", stderr3.String())
-		return "", fmt.Errorf("ffmpeg encoding failed: %v", err)
+		return nil, "", fmt.Errorf("ffmpeg encoding failed: %v", err)
 	}
 
 	// Verify output file
 	if _, err := os.Stat(finalOgg); err != nil {
-		return "", fmt.Errorf("output file not created: %v", err)
+		return nil, "", fmt.Errorf("output file not created: %v", err)
 	}
 	
 	// Check output file size
@@ -758,48 +715,20 @@ func anonymizeVoice(voiceFileID string, gender string) (string, error) {
 	// Read the processed file
 	fileBytes, err := os.ReadFile(finalOgg)
 	if err != nil {
-		return "", fmt.Errorf("failed to read output file: %v", err)
-	}
-
-	// Create a FileBytes for uploading
-	voiceMsg := tgbotapi.NewVoice(adminGroupID, tgbotapi.FileBytes{
-		Name:  "anonymized_voice.ogg",
-		Bytes: fileBytes,
-	})
-	
-	// Add caption with processing details
-	voiceMsg.Caption = fmt.Sprintf("✅ Voice Processing Complete\n\n"+
-		"Gender: %s\n"+
-		"Pitch factor: %s\n"+
-		"Speed: 100%% (unchanged)\n"+
-		"Natural voice: ✅", gender, pitchFactor)
-	
-	msg, err := bot.Send(voiceMsg)
-	if err != nil {
-		log.Printf("Telegram upload error: %v", err)
-		return "", fmt.Errorf("failed to upload processed voice: %v", err)
+		return nil, "", fmt.Errorf("failed to read output file: %v", err)
 	}
 
-	// STEP 4 — Cleanup (important for free hosting)
-	os.Remove(tempWav)
-	os.Remove(outputWav)
-
-	// Return the new file ID
-	if msg.Voice != nil {
-		log.Printf("Successfully processed and uploaded voice. New FileID: %s", msg.Voice.FileID)
-		return msg.Voice.FileID, nil
-	}
-	
-	return "", fmt.Errorf("no voice in response")
+	log.Printf("Successfully processed voice: gender=%s, pitch=%s", gender, pitchFactor)
+	return fileBytes, pitchFactor, nil
 }
 
 // ----------------- FIXED BLIND CHAT BUTTON HANDLERS -----------------
 
 func handleMainMenuButton(userID int64, chatID int64) {
-	if _, inPair := pairs[userID]; inPair {
+	if sessions.InPair(userID) {
 		// User is in blind chat - warn them
 		romanticKeyboard := createRomanticChatKeyboard()
-		activeKeyboards[chatID] = romanticKeyboard
+		sessions.SetKeyboard(chatID, romanticKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"⚠️ *You are in a blind chat!*\n\nUse '💔 End Chat' button to leave the chat first before returning to main menu.",
 			romanticKeyboard)
@@ -808,38 +737,35 @@ func handleMainMenuButton(userID int64, chatID int64) {
 	
 	// Not in chat, show main menu
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendEnhancedWelcomeMessage(chatID)
 }
 
 func handleEndBlindChatButton(userID int64, chatID int64) {
-	partner, ok := pairs[userID]
+	partner, ok := sessions.Unpair(userID)
 	if !ok {
-		if waitingUser == userID {
-			waitingUser = 0
+		if sessions.CancelWaiting(userID) {
+			deleteQueueEntry(userID)
 			mainMenuKeyboard := createMainMenuKeyboard()
-			activeKeyboards[chatID] = mainMenuKeyboard
+			sessions.SetKeyboard(chatID, mainMenuKeyboard)
 			sendMessageWithKeyboard(chatID,
 				"❌ *Search Cancelled*\n\nYou left the waiting queue.",
 				mainMenuKeyboard)
 		} else {
 			mainMenuKeyboard := createMainMenuKeyboard()
-			activeKeyboards[chatID] = mainMenuKeyboard
+			sessions.SetKeyboard(chatID, mainMenuKeyboard)
 			sendMessageWithKeyboard(chatID,
 				"⚠️ *Not in Chat*\n\nYou're not currently in a chat.",
 				mainMenuKeyboard)
 		}
 		return
 	}
-
-	// Remove from pairs
-	delete(pairs, userID)
-	delete(pairs, partner.PartnerID)
+	deleteBlindPair(userID, partner.PartnerID)
 
 	// Clean up keyboards - set main menu for both users
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[userID] = mainMenuKeyboard
-	activeKeyboards[partner.PartnerID] = mainMenuKeyboard
+	sessions.SetKeyboard(userID, mainMenuKeyboard)
+	sessions.SetKeyboard(partner.PartnerID, mainMenuKeyboard)
 
 	// Send romantic goodbye messages
 	goodbyeMessages := []string{
@@ -870,10 +796,10 @@ func handleEndBlindChatButton(userID int64, chatID int64) {
 }
 
 func handleReportButton(userID int64, chatID int64) {
-	partner, ok := pairs[userID]
+	partner, ok := sessions.Partner(userID)
 	if !ok {
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"⚠️ *Not in Chat*\n\nYou need to be in a chat to report someone.",
 			mainMenuKeyboard)
@@ -896,7 +822,7 @@ func handleReportButton(userID int64, chatID int64) {
 }
 
 func handleSendHeartButton(userID int64, chatID int64) {
-	if partner, ok := pairs[userID]; ok {
+	if partner, ok := sessions.Partner(userID); ok {
 		// Send heart to partner
 		heartMsg := tgbotapi.NewMessage(partner.PartnerID, 
 			fmt.Sprintf("❤️ *%s sent you a heart!*", partner.PartnerUsername))
@@ -906,13 +832,13 @@ func handleSendHeartButton(userID int64, chatID int64) {
 		
 		// Confirm to sender
 		romanticKeyboard := createRomanticChatKeyboard()
-		activeKeyboards[chatID] = romanticKeyboard
+		sessions.SetKeyboard(chatID, romanticKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"❤️ *Heart sent to your partner!*",
 			romanticKeyboard)
 	} else {
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"⚠️ *Not in Chat*\n\nYou need to be in a chat to send hearts.",
 			mainMenuKeyboard)
@@ -920,7 +846,7 @@ func handleSendHeartButton(userID int64, chatID int64) {
 }
 
 func handleSendSmileButton(userID int64, chatID int64) {
-	if partner, ok := pairs[userID]; ok {
+	if partner, ok := sessions.Partner(userID); ok {
 		// Send smile to partner
 		smileMsg := tgbotapi.NewMessage(partner.PartnerID, 
 			fmt.Sprintf("😊 *%s sent you a smile!*", partner.PartnerUsername))
@@ -930,13 +856,13 @@ func handleSendSmileButton(userID int64, chatID int64) {
 		
 		// Confirm to sender
 		romanticKeyboard := createRomanticChatKeyboard()
-		activeKeyboards[chatID] = romanticKeyboard
+		sessions.SetKeyboard(chatID, romanticKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"😊 *Smile sent to your partner!*",
 			romanticKeyboard)
 	} else {
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"⚠️ *Not in Chat*\n\nYou need to be in a chat to send smiles.",
 			mainMenuKeyboard)
@@ -944,15 +870,15 @@ func handleSendSmileButton(userID int64, chatID int64) {
 }
 
 func handleSendVoiceButton(userID int64, chatID int64) {
-	if _, ok := pairs[userID]; ok {
+	if sessions.InPair(userID) {
 		romanticKeyboard := createRomanticChatKeyboard()
-		activeKeyboards[chatID] = romanticKeyboard
+		sessions.SetKeyboard(chatID, romanticKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"🎤 *Hold the microphone button to record and send a voice message*\n\nYour voice will be anonymized automatically with Rubber Band!",
 			romanticKeyboard)
 	} else {
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"⚠️ *Not in Chat*\n\nYou need to be in a chat to send voice messages.",
 			mainMenuKeyboard)
@@ -960,15 +886,15 @@ func handleSendVoiceButton(userID int64, chatID int64) {
 }
 
 func handleSendPhotoButton(userID int64, chatID int64) {
-	if _, ok := pairs[userID]; ok {
+	if sessions.InPair(userID) {
 		romanticKeyboard := createRomanticChatKeyboard()
-		activeKeyboards[chatID] = romanticKeyboard
+		sessions.SetKeyboard(chatID, romanticKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"📸 *Tap the attachment icon to send a photo*\n\nPhotos are not anonymized - share carefully!",
 			romanticKeyboard)
 	} else {
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"⚠️ *Not in Chat*\n\nYou need to be in a chat to send photos.",
 			mainMenuKeyboard)
@@ -977,31 +903,31 @@ func handleSendPhotoButton(userID int64, chatID int64) {
 
 func handleCancelButton(userID int64, chatID int64) {
 	// Handle cancellation
-	if confessionType, ok := confessionWaiting[userID]; ok && confessionType != "" {
-		delete(confessionWaiting, userID)
+	if sessions.ConfessionType(userID) != "" {
+		sessions.ClearConfessionType(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"❌ *Cancelled*\n\nConfession cancelled.",
 			mainMenuKeyboard)
-	} else if userStates[userID].Step != "idle" {
-		delete(userStates, userID)
+	} else if sessions.Step(userID) != "idle" {
+		sessions.ClearState(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"❌ *Cancelled*\n\nOperation cancelled.",
 			mainMenuKeyboard)
-	} else if _, ok := commentWaiting[userID]; ok {
-		delete(commentWaiting, userID)
+	} else if _, ok := sessions.Comment(userID); ok {
+		sessions.ClearComment(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"❌ *Cancelled*\n\nComment cancelled.",
 			mainMenuKeyboard)
 	} else {
 		// Just show main menu
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"🏠 *Returned to main menu*",
 			mainMenuKeyboard)
@@ -1014,8 +940,9 @@ func createFrostedMirrorStyle(confessionText string) string {
 		return "──────────────\n🤫 Anonymous Confession\n──────────────"
 	}
 
-	// Format text with generous spacing
-	formattedText := formatConfessionText(confessionText)
+	// Format text with generous spacing; it's the author's own text, so a
+	// stray _ or * must not break the Markdown post
+	formattedText := escapeMarkdown(formatConfessionText(confessionText))
 	
 	return fmt.Sprintf("──────────────\n🤫 Anonymous Confession\n──────────────\n\n%s\n\n──────────────", formattedText)
 }
@@ -1036,12 +963,12 @@ func formatConfessionText(text string) string {
 	return strings.Join(cleanLines, "\n\n")
 }
 
-func postFrostedMirrorConfession(confessionID int, confessionType, content string, voiceID string) (int, error) {
+func postFrostedMirrorConfession(confessionID int, confessionType, content string, mediaID string) (int, error) {
 	var messageID int
 
 	if confessionType == "voice" {
 		// Voice confession
-		voiceConfig := tgbotapi.NewVoice(channelID, tgbotapi.FileID(voiceID))
+		voiceConfig := tgbotapi.NewVoice(channelID, tgbotapi.FileID(mediaID))
 		voiceConfig.Caption = createFrostedMirrorStyle("")
 		voiceConfig.ParseMode = "Markdown"
 
@@ -1051,6 +978,18 @@ func postFrostedMirrorConfession(confessionID int, confessionType, content strin
 		}
 		messageID = msg.MessageID
 
+	} else if confessionType == "photo" {
+		// Photo confession, already stripped of metadata at submission
+		photoConfig := tgbotapi.NewPhoto(channelID, tgbotapi.FileID(mediaID))
+		photoConfig.Caption = createFrostedMirrorStyle(content)
+		photoConfig.ParseMode = "Markdown"
+
+		msg, err := bot.Send(photoConfig)
+		if err != nil {
+			return 0, err
+		}
+		messageID = msg.MessageID
+
 	} else {
 		// Text confession
 		fullMessage := createFrostedMirrorStyle(content)
@@ -1066,7 +1005,7 @@ func postFrostedMirrorConfession(confessionID int, confessionType, content strin
 		messageID = msg.MessageID
 	}
 
-	// Add reaction buttons for both text and voice
+	// Add reaction buttons for every type
 	addReactionButtons(confessionID, messageID)
 
 	return messageID, nil
@@ -1091,8 +1030,7 @@ func addReactionButtons(confessionID int, messageID int) {
 	}
 	
 	// Get comment count
-	var commentCount int
-	db.QueryRow("SELECT COUNT(*) FROM confession_comments WHERE confession_id = ?", confessionID).Scan(&commentCount)
+	commentCount := visibleCommentCount(confessionID)
 	
 	// Create URL buttons for comment and view comments
 	commentURL := fmt.Sprintf("https://t.me/%s?start=comment%d", botUsername, confessionID)
@@ -1116,28 +1054,37 @@ func addReactionButtons(confessionID int, messageID int) {
 // ----------------- ENHANCED COMMENTING SYSTEM -----------------
 func handleCommentDeepLink(userID int64, chatID int64, confessionID int) {
 	// Get confession details
-	var confessionText string
+	var confessionText, status string
 	var channelMessageID int
 
 	err := db.QueryRow(`
-		SELECT text, channel_message_id 
+		SELECT COALESCE(text, ''), COALESCE(channel_message_id, 0), status
 		FROM confessions 
-		WHERE id = ?`, confessionID).Scan(&confessionText, &channelMessageID)
+		WHERE id = ?`, confessionID).Scan(&confessionText, &channelMessageID, &status)
 
 	if err != nil {
 		sendMessage(chatID, "❌ *Confession not found*\n\nThe confession you're trying to comment on doesn't exist.")
 		return
 	}
 
+	if status != "approved" {
+		sendMessage(chatID, "💭 *This confession is no longer available*")
+		return
+	}
+
+	if !allowAction(userID, chatID, ActionComment) {
+		return
+	}
+
 	// Set user to waiting for comment
-	commentWaiting[userID] = CommentData{
+	sessions.SetComment(userID, CommentData{
 		ConfessionID:      confessionID,
-		MessageID:        channelMessageID,
-		ConfessionText:   confessionText,
-		UserID:          userID,
+		MessageID:         channelMessageID,
+		ConfessionText:    confessionText,
+		UserID:            userID,
 		WaitingForComment: true,
 		IsViewingComments: false,
-	}
+	})
 
 	// Send comment interface
 	msg := tgbotapi.NewMessage(chatID,
@@ -1153,7 +1100,7 @@ func handleCommentDeepLink(userID int64, chatID int64, confessionID int) {
 📋 *Guidelines:*
 • Keep it respectful & constructive
 • Stay anonymous (no one sees your identity)
-• Max 500 characters
+• Max %d characters
 • No personal information
 • No harassment
 
@@ -1164,65 +1111,13 @@ func handleCommentDeepLink(userID int64, chatID int64, confessionID int) {
 
 ──────────────
 *Your voice matters. Comment respectfully.*`,
-			confessionID, formatConfessionText(confessionText)))
+			confessionID, formatConfessionText(confessionText), cfg.CommentMaxLength))
 	msg.ParseMode = "Markdown"
-	activeKeyboards[chatID] = createMainMenuKeyboard()
+	sessions.SetKeyboard(chatID, createMainMenuKeyboard())
 	msg.ReplyMarkup = createMainMenuKeyboard()
 	bot.Send(msg)
 }
 
-func handleViewCommentsDeepLink(userID int64, chatID int64, confessionID int) {
-	// Get comments from database
-	rows, err := db.Query(`
-		SELECT text, created_at 
-		FROM confession_comments 
-		WHERE confession_id = ? 
-		ORDER BY created_at DESC 
-		LIMIT 20`, confessionID)
-
-	if err != nil {
-		sendMessage(chatID, "❌ *Error loading comments*")
-		return
-	}
-	defer rows.Close()
-
-	var comments []string
-	for rows.Next() {
-		var text, createdAt string
-		rows.Scan(&text, &createdAt)
-
-		// Format time
-		t, _ := time.Parse("2006-01-02 15:04:05", createdAt)
-		timeStr := t.Format("3:04 PM")
-
-		comments = append(comments, fmt.Sprintf("💬 *Anonymous* (%s):\n%s", timeStr, text))
-	}
-
-	if len(comments) == 0 {
-		sendMessage(chatID, fmt.Sprintf("💭 *No comments yet for Confession #%d*\n\nBe the first to comment! 🤫", confessionID))
-		return
-	}
-
-	// Create comment summary
-	commentText := fmt.Sprintf("📊 *Comments on Confession #%d*\n──────────────\n\n", confessionID)
-
-	for i, comment := range comments {
-		commentText += fmt.Sprintf("%s\n──────────────\n", comment)
-		if i == 9 { // Show only first 10 comments
-			commentText += "... and more comments available\n\n"
-			break
-		}
-	}
-
-	commentText += fmt.Sprintf("📊 *Total: %d comments*\n", len(comments))
-	commentText += "──────────────\n"
-	commentText += "💬 *Want to add a comment?*\n"
-	commentText += "Click the '💬 Comment' button in the channel!"
-
-	// Send comment summary
-	sendMessage(chatID, commentText)
-}
-
 func handleUserComment(userID int64, chatID int64, msg *tgbotapi.Message) {
 	if chatID != userID {
 		sendMessage(chatID, "🔒 *Please send comments in private chat only*")
@@ -1231,9 +1126,9 @@ func handleUserComment(userID int64, chatID int64, msg *tgbotapi.Message) {
 
 	// Check for cancel
 	if msg.Text == "❌ Cancel" || msg.Text == "🏠 Main Menu" {
-		delete(commentWaiting, userID)
+		sessions.ClearComment(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"❌ *Comment cancelled*\n\nReturned to main menu.",
 			mainMenuKeyboard)
@@ -1241,31 +1136,58 @@ func handleUserComment(userID int64, chatID int64, msg *tgbotapi.Message) {
 	}
 
 	if msg.Text == "" || len(msg.Text) < 1 {
-		sendMessage(chatID, "📝 *Please write a valid comment (1-500 characters)*")
+		sendMessage(chatID, fmt.Sprintf("📝 *Please write a valid comment (1-%d characters)*", cfg.CommentMaxLength))
+		return
+	}
+
+	if len(msg.Text) > cfg.CommentMaxLength {
+		sendMessage(chatID, fmt.Sprintf("📏 *Too long*\n\nComments must be under %d characters.", cfg.CommentMaxLength))
+		return
+	}
+
+	moderation := moderateText(userID, "comment", msg.Text)
+	if hit, rejected := moderation.Rejected(); rejected {
+		sendModerationRejection(chatID, hit, "comment")
 		return
 	}
 
-	if len(msg.Text) > 500 {
-		sendMessage(chatID, "📏 *Too long*\n\nComments must be under 500 characters.")
+	commentData, _ := sessions.Comment(userID)
+
+	if !allowAction(userID, chatID, ActionComment) {
+		sessions.ClearComment(userID)
 		return
 	}
 
-	commentData := commentWaiting[userID]
+	status := "visible"
+	if cfg.CommentApproval {
+		status = "pending"
+	}
 
 	// Save comment to database
-	err := saveComment(commentData.ConfessionID, userID, msg.From.UserName, msg.Text)
+	commentID, err := saveComment(commentData.ConfessionID, commentData.ParentID, userID, msg.From.UserName, msg.Text, status)
 	if err != nil {
 		log.Println("Error saving comment:", err)
 		sendMessage(chatID, "❌ *Error*\n\nFailed to save comment. Please try again.")
-		delete(commentWaiting, userID)
+		sessions.ClearComment(userID)
 		return
 	}
 
-	// Update comment count in the channel
-	updateCommentCount(commentData.ConfessionID, commentData.MessageID)
+	recordAction(userID, ActionComment)
+
+	if cfg.CommentApproval {
+		sendCommentToAdmins(commentID, "💬 *NEW COMMENT*", moderationFlagsText(moderation))
+	} else {
+		if moderation.Flagged() {
+			sendCommentToAdmins(commentID, "🛡️ *FLAGGED COMMENT*", moderationFlagsText(moderation))
+		}
+
+		// Update comment count in the channel
+		updateCommentCount(commentData.ConfessionID, commentData.MessageID)
+		onCommentVisible(commentID)
+	}
 
 	// Clear waiting state
-	delete(commentWaiting, userID)
+	sessions.ClearComment(userID)
 
 	// Send confirmation
 	confirmationMsg := fmt.Sprintf(`✅ *COMMENT ADDED ANONYMOUSLY*
@@ -1280,26 +1202,43 @@ func handleUserComment(userID int64, chatID int64, msg *tgbotapi.Message) {
 ──────────────
 *Thank you for contributing respectfully!* ✨`,
 		commentData.ConfessionID)
+	if cfg.CommentApproval {
+		confirmationMsg = fmt.Sprintf(`⏳ *COMMENT SENT FOR REVIEW*
+──────────────
 
-	activeKeyboards[chatID] = createMainMenuKeyboard()
+💭 *Your anonymous comment on Confession #%d is waiting for approval*
+
+✅ *You'll be notified once an admin reviews it*
+🔒 *No one can see your identity*
+
+──────────────
+*Thank you for contributing respectfully!* ✨`,
+			commentData.ConfessionID)
+	}
+
+	sessions.SetKeyboard(chatID, createMainMenuKeyboard())
 	sendMessageWithKeyboard(chatID, confirmationMsg, createMainMenuKeyboard())
 }
 
-func saveComment(confessionID int, userID int64, username string, text string) error {
-	_, err := db.Exec(`
-		INSERT INTO confession_comments (confession_id, user_id, username, text, anonymous)
-		VALUES (?, ?, ?, ?, 1)`,
-		confessionID, userID, username, text)
-	return err
+// saveComment stores a comment; parentID is 0 for top-level comments
+func saveComment(confessionID int, parentID int64, userID int64, username string, text string, status string) (int64, error) {
+	var parent interface{}
+	if parentID != 0 {
+		parent = parentID
+	}
+	result, err := db.Exec(`
+		INSERT INTO confession_comments (confession_id, parent_id, user_id, username, text, anonymous, status)
+		VALUES (?, ?, ?, ?, ?, 1, ?)`,
+		confessionID, parent, userID, username, text, status)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
 }
 
 func updateCommentCount(confessionID int, channelMessageID int) {
-	// Get current comment count
-	var count int
-	db.QueryRow("SELECT COUNT(*) FROM confession_comments WHERE confession_id = ?", confessionID).Scan(&count)
-
 	// Update the buttons in the channel message
-	updateChannelButtons(confessionID, channelMessageID, count)
+	updateChannelButtons(confessionID, channelMessageID, visibleCommentCount(confessionID))
 }
 
 func updateChannelButtons(confessionID int, channelMessageID int, commentCount int) {
@@ -1346,38 +1285,54 @@ func updateChannelButtons(confessionID int, channelMessageID int, commentCount i
 
 // ----------------- ENHANCED CONFESSION SYSTEM -----------------
 func startConfessionFlow(userID int64, chatID int64) {
-	activeKeyboards[chatID] = createConfessionTypeKeyboard()
+	sessions.SetKeyboard(chatID, createConfessionTypeKeyboard())
 	sendMessageWithKeyboard(chatID,
 		"🤫 *Choose Confession Type*\n──────────────\n\n"+
 			"✨ *Express yourself anonymously*\n\n"+
 			"📝 *Text Confession* - Write your thoughts\n"+
-			"🎤 *Voice Confession* - Speak from the heart\n\n"+
+			"🎤 *Voice Confession* - Speak from the heart\n"+
+			"📸 *Photo Confession* - Share a picture, metadata removed\n\n"+
 			"──────────────\n"+
-			"*Both are presented in the frosted mirror style*",
+			"*All are presented in the frosted mirror style*",
 		createConfessionTypeKeyboard())
 }
 
 func handleTextConfessionButton(userID int64, chatID int64) {
-	confessionWaiting[userID] = "text"
-	activeKeyboards[chatID] = createCancelKeyboard()
+	if !allowConfession(userID, chatID, "text") {
+		return
+	}
+
+	sessions.SetConfessionType(userID, "text")
+	sessions.SetKeyboard(chatID, createCancelKeyboard())
 	sendMessageWithKeyboard(chatID,
 		"📝 *Text Confession*\n──────────────\n\n"+
 			"✨ *Write your heart out anonymously!*\n\n"+
 			"📋 *Guidelines:*\n"+
-			"• Min 10 characters\n"+
-			"• Max 2000 characters\n"+
+			fmt.Sprintf("• Min %d characters\n", cfg.ConfessionMinLength)+
+			fmt.Sprintf("• Max %d characters\n", cfg.ConfessionMaxLength)+
 			"• Be respectful\n"+
 			"• No personal info\n\n"+
 			"💫 *Your confession will use frosted mirror style*\n"+
+			"👁️ *You'll see a preview before it's sent*\n"+
 			"✅ *Approved confessions go to channel*\n\n"+
 			"──────────────\n"+
 			"*Write your confession now...*",
 		createCancelKeyboard())
+
+	// A draft left from earlier can still be submitted; new text replaces it
+	if draft, revision, ok := getDraft(userID); ok {
+		sendMessage(chatID, "📝 *You have an unsent draft*\n\nSubmit it below, or write a new confession to replace it.")
+		sendDraftPreview(chatID, draft, revision)
+	}
 }
 
 func handleVoiceConfessionButton(userID int64, chatID int64) {
-	confessionWaiting[userID] = "voice"
-	activeKeyboards[chatID] = createCancelKeyboard()
+	if !allowConfession(userID, chatID, "voice") {
+		return
+	}
+
+	sessions.SetConfessionType(userID, "voice")
+	sessions.SetKeyboard(chatID, createCancelKeyboard())
 	sendMessageWithKeyboard(chatID,
 		"🎤 *Voice Confession*\n──────────────\n\n"+
 			"✨ *Speak your heart out anonymously!*\n\n"+
@@ -1388,7 +1343,7 @@ func handleVoiceConfessionButton(userID int64, chatID int64) {
 			"• 100% untraceable to your real voice\n\n"+
 			"📝 *Instructions:*\n"+
 			"1. Press and hold microphone button\n"+
-			"2. Record your confession (max 2 minutes)\n"+
+			fmt.Sprintf("2. Record your confession (max %s)\n", formatSeconds(cfg.VoiceConfessionMaxSeconds))+
 			"3. Release to send\n\n"+
 			"💫 *Your voice will use frosted mirror style*\n"+
 			"✅ *Approved voice confessions go to channel*\n\n"+
@@ -1400,9 +1355,9 @@ func handleVoiceConfessionButton(userID int64, chatID int64) {
 func handleConfessionContent(userID int64, chatID int64, msg *tgbotapi.Message, confessionType string) {
 	// Check if user wants to cancel
 	if msg.Text == "❌ Cancel" {
-		delete(confessionWaiting, userID)
+		sessions.ClearConfessionType(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID, "❌ *Cancelled*\n\nConfession cancelled.", mainMenuKeyboard)
 		return
 	}
@@ -1412,9 +1367,9 @@ func handleConfessionContent(userID int64, chatID int64, msg *tgbotapi.Message,
 		voiceID := msg.Voice.FileID
 		duration := msg.Voice.Duration
 
-		if duration > 120 {
+		if duration > cfg.VoiceConfessionMaxSeconds {
 			sendMessageWithKeyboard(chatID,
-				"⏱️ *Too Long*\n\nVoice confession must be under 2 minutes.",
+				fmt.Sprintf("⏱️ *Too Long*\n\nVoice confession must be under %s.", formatSeconds(cfg.VoiceConfessionMaxSeconds)),
 				createCancelKeyboard())
 			return
 		}
@@ -1426,38 +1381,35 @@ func handleConfessionContent(userID int64, chatID int64, msg *tgbotapi.Message,
 			gender = "male" // default
 		}
 
-		// Anonymize voice using Rubber Band pipeline
-		sendMessageWithKeyboard(chatID,
-			"🔊 *Processing your voice...*\n\nApplying Rubber Band voice anonymization...",
-			createCancelKeyboard())
-
-		anonymizedVoiceID, err := anonymizeVoice(voiceID, gender)
-		if err != nil {
-			log.Println("Error anonymizing voice:", err)
-			sendMessageWithKeyboard(chatID,
-				"❌ *Voice Processing Failed*\n\nPlease try again or use text confession.",
-				createCancelKeyboard())
-			delete(confessionWaiting, userID)
+		// The limit may have been reached since the prompt was shown
+		if !allowConfession(userID, chatID, "voice") {
+			sessions.ClearConfessionType(userID)
 			return
 		}
 
-		// Save voice confession with anonymized voice ID
-		confessionID, err := saveVoiceConfession(userID, anonymizedVoiceID)
+		// Anonymize in the background; the worker sends it to admins when done
+		sessions.ClearConfessionType(userID)
+		mainMenuKeyboard := createMainMenuKeyboard()
+		sendMessageWithKeyboard(chatID,
+			"🔊 *Voice received*\n\nApplying Rubber Band voice anonymization in the background. You can keep using the bot meanwhile.",
+			mainMenuKeyboard)
+
+		_, err = enqueueVoiceJob(VoiceJob{
+			Kind:     "confession",
+			UserID:   userID,
+			ChatID:   chatID,
+			FileID:   voiceID,
+			Gender:   gender,
+			Duration: duration,
+		})
 		if err != nil {
-			log.Println("Error saving voice confession:", err)
+			log.Println("Error queueing voice confession:", err)
 			sendMessageWithKeyboard(chatID,
 				"❌ *Error*\n\nFailed to save voice confession. Please try again.",
-				createCancelKeyboard())
-			delete(confessionWaiting, userID)
+				mainMenuKeyboard)
 			return
 		}
-
-		// Send to admin for approval with the ANONYMIZED voice
-		sendVoiceToAdmin(int(confessionID), userID, anonymizedVoiceID, duration)
-		sendConfessionSubmittedMessage(chatID, "voice")
-		delete(confessionWaiting, userID)
-		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		recordConfession(userID, "voice")
 		return
 	}
 
@@ -1465,44 +1417,60 @@ func handleConfessionContent(userID int64, chatID int64, msg *tgbotapi.Message,
 	if confessionType == "text" && msg.Text != "" {
 		text := msg.Text
 
-		if len(text) < 10 {
+		if len(text) < cfg.ConfessionMinLength {
 			sendMessageWithKeyboard(chatID,
-				"📏 *Too Short*\n\nConfession must be at least 10 characters.",
+				fmt.Sprintf("📏 *Too Short*\n\nConfession must be at least %d characters.", cfg.ConfessionMinLength),
 				createCancelKeyboard())
 			return
 		}
 
-		if len(text) > 2000 {
+		if len(text) > cfg.ConfessionMaxLength {
 			sendMessageWithKeyboard(chatID,
-				"📏 *Too Long*\n\nConfession must be under 2000 characters.",
+				fmt.Sprintf("📏 *Too Long*\n\nConfession must be under %d characters.", cfg.ConfessionMaxLength),
 				createCancelKeyboard())
 			return
 		}
 
-		confessionID, err := saveTextConfession(userID, text)
+		if hit, rejected := moderateText(userID, "confession", text).Rejected(); rejected {
+			sendModerationRejection(chatID, hit, "confession")
+			return
+		}
+
+		// Keep it as a draft; it goes to the admins from the preview's Submit button
+		revision, err := saveDraft(userID, text)
 		if err != nil {
-			log.Println("Error saving confession:", err)
+			log.Println("Error saving draft:", err)
 			sendMessageWithKeyboard(chatID,
 				"❌ *Error*\n\nFailed to save confession. Please try again.",
 				createCancelKeyboard())
-			delete(confessionWaiting, userID)
 			return
 		}
 
-		// Send to admin for approval
-		sendTextToAdmin(int(confessionID), userID, text)
-		sendConfessionSubmittedMessage(chatID, "text")
-		delete(confessionWaiting, userID)
+		sessions.ClearConfessionType(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID, "📝 *Draft saved*\n\nNothing is sent until you press ✅ Submit.", mainMenuKeyboard)
+		sendDraftPreview(chatID, text, revision)
 		return
 	}
 
+	// Handle photo confession
+	if confessionType == "photo" {
+		if fileID, ok := confessionPhotoFileID(msg); ok {
+			handlePhotoConfession(userID, chatID, fileID, msg.Caption)
+			return
+		}
+	}
+
 	// If no valid content
 	if confessionType == "voice" {
 		sendMessageWithKeyboard(chatID,
 			"❓ *Invalid Content*\n\nPlease send a voice message.",
 			createCancelKeyboard())
+	} else if confessionType == "photo" {
+		sendMessageWithKeyboard(chatID,
+			"❓ *Invalid Content*\n\nPlease send a photo.",
+			createCancelKeyboard())
 	} else {
 		sendMessageWithKeyboard(chatID,
 			"❓ *Invalid Content*\n\nPlease send text.",
@@ -1515,6 +1483,8 @@ func sendConfessionSubmittedMessage(chatID int64, confessionType string) {
 
 	if confessionType == "voice" {
 		message += "🎤 *Voice confession captured & anonymized*\n\n"
+	} else if confessionType == "photo" {
+		message += "📸 *Photo confession cleaned of metadata*\n\n"
 	} else {
 		message += "📝 *Text confession written*\n\n"
 	}
@@ -1528,7 +1498,7 @@ func sendConfessionSubmittedMessage(chatID int64, confessionType string) {
 		"*Your confession will appear in the channel when approved.*"
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendMessageWithKeyboard(chatID, message, mainMenuKeyboard)
 }
 
@@ -1552,28 +1522,34 @@ func handleBlindDatingCommand(userID int64, chatID int64) {
 	}
 
 	// Check if already in chat
-	if _, ok := pairs[userID]; ok {
+	if sessions.InPair(userID) {
 		romanticKeyboard := createRomanticChatKeyboard()
-		activeKeyboards[chatID] = romanticKeyboard
+		sessions.SetKeyboard(chatID, romanticKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"💬 *Already Connected*\n\nYou're in a chat. Use '💔 End Chat' button to leave.",
 			romanticKeyboard)
 		return
 	}
 
-	// Find matching partner with opposite gender restriction
-	partnerID := findMatchingPartner(userID, profile)
+	// Pick the best compatible partner from the waiting pool
+	partnerID, taken := findMatchingPartner(userID, profile)
+	if taken {
+		// Another searcher matched this user meanwhile and connects the pair
+		return
+	}
 	if partnerID == 0 {
-		// No match found, join waiting
-		waitingUser = userID
+		// No match found, join the pool (keeps the original join time on repeat searches)
+		sessions.JoinWaiting(userID, time.Now())
+		saveQueueEntry(userID)
 		cancelSearchKeyboard := createCancelSearchKeyboard()
-		activeKeyboards[chatID] = cancelSearchKeyboard
+		sessions.SetKeyboard(chatID, cancelSearchKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"🔍 *Finding Your Match...*\n──────────────\n\n"+
 				"✨ *Based on your preferences:*\n"+
 				fmt.Sprintf("• 👫 Gender: %s\n", profile.PrefGender)+
 				fmt.Sprintf("• 🎂 Age: %d-%d years\n", profile.PrefAgeMin, profile.PrefAgeMax)+
 				fmt.Sprintf("• 🎓 Year: %s\n\n", profile.YearOfStudy)+
+				fmt.Sprintf("👥 *People searching now:* %d\n\n", len(sessions.WaitingUsers()))+
 				"💝 *Looking for compatible heart...*\n\n"+
 				"──────────────\n"+
 				"*This may take a few moments*",
@@ -1586,17 +1562,13 @@ func handleBlindDatingCommand(userID int64, chatID int64) {
 }
 
 func startProfileCreation(userID int64, chatID int64, gender string) {
-	userStates[userID] = &UserState{
-		Step:       "profile_age",
-		Data:       make(map[string]interface{}),
-		LastActive: time.Now(),
-	}
-
 	// Pre-fill gender from user's profile
-	userStates[userID].Data["gender"] = gender
+	sessions.StartStep(userID, "profile_age", map[string]interface{}{
+		"gender": gender,
+	})
 
 	cancelKeyboard := createCancelKeyboard()
-	activeKeyboards[chatID] = cancelKeyboard
+	sessions.SetKeyboard(chatID, cancelKeyboard)
 	sendMessageWithKeyboard(chatID,
 		fmt.Sprintf(`💝 *Blind Connection Profile*
 ─────────────────────────────
@@ -1612,12 +1584,7 @@ func startProfileCreation(userID int64, chatID int64, gender string) {
 }
 
 func handleUserState(userID int64, chatID int64, msg *tgbotapi.Message) {
-	state := userStates[userID]
-	if state == nil {
-		return
-	}
-
-	switch state.Step {
+	switch sessions.Step(userID) {
 	case "profile_age":
 		handleProfileAge(userID, chatID, msg.Text)
 
@@ -1635,18 +1602,29 @@ func handleUserState(userID int64, chatID int64, msg *tgbotapi.Message) {
 
 	case "admin_contact":
 		handleAdminContactMessage(userID, chatID, msg)
+
+	case "appeal":
+		handleAppealMessage(userID, chatID, msg)
+
+	case "contact_reply":
+		handleAdminContactReply(userID, chatID, msg)
+
+	case "reject_reason":
+		handleRejectReasonReply(userID, chatID, msg)
+
+	case "contact_followup":
+		handleContactFollowUp(userID, chatID, msg)
 	}
 }
 
 func handleProfileAge(userID int64, chatID int64, ageStr string) {
 	// Check for cancel
 	if ageStr == "❌ Cancel" {
-		delete(userStates, userID)
+		cancelText := profileCancelText(userID)
+		sessions.ClearState(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
-		sendMessageWithKeyboard(chatID,
-			"❌ *Cancelled*\n\nProfile creation cancelled.",
-			mainMenuKeyboard)
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID, cancelText, mainMenuKeyboard)
 		return
 	}
 
@@ -1658,8 +1636,13 @@ func handleProfileAge(userID int64, chatID int64, ageStr string) {
 		return
 	}
 
-	userStates[userID].Data["age"] = age
-	userStates[userID].Step = "profile_years_campus"
+	sessions.SetData(userID, "age", age)
+
+	if isEditingProfile(userID) {
+		finishProfileEdit(userID, chatID)
+		return
+	}
+	sessions.SetStep(userID, "profile_years_campus")
 
 	sendMessageWithKeyboard(chatID,
 		"✅ *Age saved*\n──────────────\n\n"+
@@ -1671,12 +1654,11 @@ func handleProfileAge(userID int64, chatID int64, ageStr string) {
 func handleProfileYearsCampus(userID int64, chatID int64, yearsStr string) {
 	// Check for cancel
 	if yearsStr == "❌ Cancel" {
-		delete(userStates, userID)
+		cancelText := profileCancelText(userID)
+		sessions.ClearState(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
-		sendMessageWithKeyboard(chatID,
-			"❌ *Cancelled*\n\nProfile creation cancelled.",
-			mainMenuKeyboard)
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID, cancelText, mainMenuKeyboard)
 		return
 	}
 
@@ -1688,11 +1670,16 @@ func handleProfileYearsCampus(userID int64, chatID int64, yearsStr string) {
 		return
 	}
 
-	userStates[userID].Data["years_on_campus"] = years
-	userStates[userID].Step = "profile_year_study"
+	sessions.SetData(userID, "years_on_campus", years)
+
+	if isEditingProfile(userID) {
+		finishProfileEdit(userID, chatID)
+		return
+	}
+	sessions.SetStep(userID, "profile_year_study")
 
 	yearStudyKeyboard := createYearStudyKeyboard()
-	activeKeyboards[chatID] = yearStudyKeyboard
+	sessions.SetKeyboard(chatID, yearStudyKeyboard)
 	sendMessageWithKeyboard(chatID,
 		"✅ *Years saved*\n──────────────\n\n"+
 			"*Step 3 of 6:* Current year of study?\n\n"+
@@ -1703,12 +1690,11 @@ func handleProfileYearsCampus(userID int64, chatID int64, yearsStr string) {
 func handleProfileYearStudy(userID int64, chatID int64, year string) {
 	// Check for cancel
 	if year == "❌ Cancel" {
-		delete(userStates, userID)
+		cancelText := profileCancelText(userID)
+		sessions.ClearState(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
-		sendMessageWithKeyboard(chatID,
-			"❌ *Cancelled*\n\nProfile creation cancelled.",
-			mainMenuKeyboard)
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID, cancelText, mainMenuKeyboard)
 		return
 	}
 
@@ -1729,11 +1715,16 @@ func handleProfileYearStudy(userID int64, chatID int64, year string) {
 		return
 	}
 
-	userStates[userID].Data["year_of_study"] = year
-	userStates[userID].Step = "profile_pref_gender"
+	sessions.SetData(userID, "year_of_study", year)
+
+	if isEditingProfile(userID) {
+		finishProfileEdit(userID, chatID)
+		return
+	}
+	sessions.SetStep(userID, "profile_pref_gender")
 
 	prefGenderKeyboard := createPrefGenderKeyboard()
-	activeKeyboards[chatID] = prefGenderKeyboard
+	sessions.SetKeyboard(chatID, prefGenderKeyboard)
 	sendMessageWithKeyboard(chatID,
 		"✅ *Year saved*\n──────────────\n\n"+
 			"*Step 4 of 6:* Preferred gender to meet?\n\n"+
@@ -1744,12 +1735,11 @@ func handleProfileYearStudy(userID int64, chatID int64, year string) {
 func handleProfilePrefGender(userID int64, chatID int64, pref string) {
 	// Check for cancel
 	if pref == "❌ Cancel" {
-		delete(userStates, userID)
+		cancelText := profileCancelText(userID)
+		sessions.ClearState(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
-		sendMessageWithKeyboard(chatID,
-			"❌ *Cancelled*\n\nProfile creation cancelled.",
-			mainMenuKeyboard)
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID, cancelText, mainMenuKeyboard)
 		return
 	}
 
@@ -1768,11 +1758,25 @@ func handleProfilePrefGender(userID int64, chatID int64, pref string) {
 		return
 	}
 
-	userStates[userID].Data["pref_gender"] = prefValue
-	userStates[userID].Step = "profile_pref_age"
+	// Under the opposite-gender policy a same-gender-only preference could never match
+	rawGender, _ := sessions.GetData(userID, "gender")
+	if gender, _ := rawGender.(string); cfg.OppositeGenderOnly && prefValue == gender {
+		sendMessageWithKeyboard(chatID,
+			"❌ *Preference not available*\n\nThis community uses opposite gender matching only. Please pick another option.",
+			createPrefGenderKeyboard())
+		return
+	}
+
+	sessions.SetData(userID, "pref_gender", prefValue)
+
+	if isEditingProfile(userID) {
+		finishProfileEdit(userID, chatID)
+		return
+	}
+	sessions.SetStep(userID, "profile_pref_age")
 
 	cancelKeyboard := createCancelKeyboard()
-	activeKeyboards[chatID] = cancelKeyboard
+	sessions.SetKeyboard(chatID, cancelKeyboard)
 	sendMessageWithKeyboard(chatID,
 		"✅ *Preference saved*\n──────────────\n\n"+
 			"*Step 5 of 6:* Preferred age range?\n\n"+
@@ -1783,19 +1787,17 @@ func handleProfilePrefGender(userID int64, chatID int64, pref string) {
 func handleProfilePrefAge(userID int64, chatID int64, ageStr string) {
 	// Check for cancel
 	if ageStr == "❌ Cancel" {
-		delete(userStates, userID)
+		cancelText := profileCancelText(userID)
+		sessions.ClearState(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
-		sendMessageWithKeyboard(chatID,
-			"❌ *Cancelled*\n\nProfile creation cancelled.",
-			mainMenuKeyboard)
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
+		sendMessageWithKeyboard(chatID, cancelText, mainMenuKeyboard)
 		return
 	}
 
-	state := userStates[userID]
-
 	// First message is min age
-	if _, hasMin := state.Data["pref_age_min"]; !hasMin {
+	minValue, hasMin := sessions.GetData(userID, "pref_age_min")
+	if !hasMin {
 		minAge, err := strconv.Atoi(ageStr)
 		if err != nil || minAge < 18 || minAge > 50 {
 			sendMessageWithKeyboard(chatID,
@@ -1804,7 +1806,7 @@ func handleProfilePrefAge(userID int64, chatID int64, ageStr string) {
 			return
 		}
 
-		state.Data["pref_age_min"] = minAge
+		sessions.SetData(userID, "pref_age_min", minAge)
 		sendMessageWithKeyboard(chatID,
 			fmt.Sprintf("✅ *Min age: %d*\n\n✨ *Now enter maximum age (18-50, >= %d):*", minAge, minAge),
 			createCancelKeyboard())
@@ -1820,7 +1822,7 @@ func handleProfilePrefAge(userID int64, chatID int64, ageStr string) {
 		return
 	}
 
-	minAge := state.Data["pref_age_min"].(int)
+	minAge := minValue.(int)
 	if maxAge < minAge || maxAge > 50 {
 		sendMessageWithKeyboard(chatID,
 			fmt.Sprintf("❌ *Invalid range*\n\nMax age must be between %d and 50.", minAge),
@@ -1828,34 +1830,25 @@ func handleProfilePrefAge(userID int64, chatID int64, ageStr string) {
 		return
 	}
 
-	state.Data["pref_age_max"] = maxAge
+	sessions.SetData(userID, "pref_age_max", maxAge)
+
+	if isEditingProfile(userID) {
+		finishProfileEdit(userID, chatID)
+		return
+	}
 
 	// Save complete profile
-	saveBlindProfile(userID, state.Data)
-	delete(userStates, userID)
+	saveBlindProfile(userID, sessions.Data(userID))
+	sessions.ClearState(userID)
 
 	// Show profile summary
 	showBlindProfile(userID, chatID)
 }
 
-func findMatchingPartner(userID int64, profile *BlindProfile) int64 {
-	// Don't match with yourself
-	if waitingUser != 0 && waitingUser != userID {
-		waitingProfile, err := getBlindProfile(waitingUser)
-		if err == nil && waitingProfile.ProfileSet {
-			// Check mutual compatibility with opposite gender restriction
-			if areProfilesCompatible(profile, waitingProfile) {
-				return waitingUser
-			}
-		}
-	}
-	return 0
-}
-
 func areProfilesCompatible(p1, p2 *BlindProfile) bool {
-	// Gender compatibility - ENFORCE OPPOSITE GENDER ONLY
-	if p1.Gender == p2.Gender {
-		return false // Same gender not allowed
+	// Optional community policy: opposite gender matching only
+	if cfg.OppositeGenderOnly && p1.Gender == p2.Gender {
+		return false
 	}
 
 	// Check preferences
@@ -1888,20 +1881,29 @@ func isAgeCompatible(p1, p2 *BlindProfile) bool {
 	return p2.Age >= p1.PrefAgeMin && p2.Age <= p1.PrefAgeMax
 }
 
+// matchingPolicyText describes the active gender matching policy for the
+// help, rules and profile screens
+func matchingPolicyText() string {
+	if cfg.OppositeGenderOnly {
+		return "Opposite gender matching only"
+	}
+	return "Matches respect both users' gender preferences"
+}
+
 func connectBlindPair(userID, partnerID int64) {
 	// Get usernames for both users
 	var userUsername, partnerUsername string
 	var userName, partnerName string
 
 	// Get user's username from state or database
-	if state, ok := userStates[userID]; ok && state.Data["username"] != nil {
-		userUsername = state.Data["username"].(string)
+	if username, ok := sessions.GetData(userID, "username"); ok && username != nil {
+		userUsername = username.(string)
 	} else {
 		db.QueryRow("SELECT username, first_name FROM users WHERE user_id = ?", userID).Scan(&userUsername, &userName)
 	}
 
-	if state, ok := userStates[partnerID]; ok && state.Data["username"] != nil {
-		partnerUsername = state.Data["username"].(string)
+	if username, ok := sessions.GetData(partnerID, "username"); ok && username != nil {
+		partnerUsername = username.(string)
 	} else {
 		db.QueryRow("SELECT username, first_name FROM users WHERE user_id = ?", partnerID).Scan(&partnerUsername, &partnerName)
 	}
@@ -1914,19 +1916,18 @@ func connectBlindPair(userID, partnerID int64) {
 		partnerUsername = partnerName
 	}
 
-	pairs[userID] = BlindChatPair{
+	userSide := BlindChatPair{
 		PartnerID:       partnerID,
 		PartnerUsername: partnerUsername,
 		PartnerName:     partnerName,
 	}
-
-	pairs[partnerID] = BlindChatPair{
+	partnerSide := BlindChatPair{
 		PartnerID:       userID,
 		PartnerUsername: userUsername,
 		PartnerName:     userName,
 	}
-
-	waitingUser = 0
+	sessions.Pair(userID, userSide, partnerID, partnerSide)
+	saveBlindPair(userID, userSide, partnerID, partnerSide)
 
 	// Log the connection
 	log.Printf("💝 Blind pair connected: %d + %d", userID, partnerID)
@@ -1960,8 +1961,8 @@ func connectBlindPair(userID, partnerID int64) {
 
 	// Set romantic keyboard for both users
 	romanticKeyboard := createRomanticChatKeyboard()
-	activeKeyboards[userID] = romanticKeyboard
-	activeKeyboards[partnerID] = romanticKeyboard
+	sessions.SetKeyboard(userID, romanticKeyboard)
+	sessions.SetKeyboard(partnerID, romanticKeyboard)
 
 	// Send to both users with romantic keyboards
 	sendMessageWithKeyboard(userID, connectionMsg, romanticKeyboard)
@@ -1971,22 +1972,14 @@ func connectBlindPair(userID, partnerID int64) {
 // ----------------- ADMIN CONTACT SYSTEM -----------------
 func startAdminContact(userID int64, chatID int64) {
 	// Check if user can contact admin
-	if !canContactAdmin(userID) {
-		sendMessage(chatID,
-			"⏳ *Rate Limited*\n\n"+
-				"You can contact admin once per week.\n"+
-				"Please wait before sending another message.")
+	if !allowAction(userID, chatID, ActionAdminContact) {
 		return
 	}
 
-	userStates[userID] = &UserState{
-		Step:       "admin_contact",
-		Data:       make(map[string]interface{}),
-		LastActive: time.Now(),
-	}
+	sessions.StartStep(userID, "admin_contact", nil)
 
 	cancelKeyboard := createCancelKeyboard()
-	activeKeyboards[chatID] = cancelKeyboard
+	sessions.SetKeyboard(chatID, cancelKeyboard)
 	sendMessageWithKeyboard(chatID,
 		"📞 *Contact Admin*\n──────────────\n\n"+
 			"✨ *Send one message to admin team*\n\n"+
@@ -1996,52 +1989,50 @@ func startAdminContact(userID int64, chatID int64) {
 			"• Reports\n"+
 			"• Feedback\n\n"+
 			"──────────────\n"+
-			"*Note:* One-way message only\n"+
-			"Admin will contact you if needed.",
+			"*Note:* Admins reply anonymously\n"+
+			"You can answer back in the same thread.",
 		cancelKeyboard)
 }
 
 func handleAdminContactMessage(userID int64, chatID int64, msg *tgbotapi.Message) {
 	// Check for cancel
 	if msg.Text == "❌ Cancel" {
-		delete(userStates, userID)
+		sessions.ClearState(userID)
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[chatID] = mainMenuKeyboard
+		sessions.SetKeyboard(chatID, mainMenuKeyboard)
 		sendMessageWithKeyboard(chatID,
 			"❌ *Cancelled*\n\nAdmin contact cancelled.",
 			mainMenuKeyboard)
 		return
 	}
 
-	delete(userStates, userID)
+	if msg.Text == "" {
+		sendMessageWithKeyboard(chatID,
+			"❌ *Text Only*\n\nPlease write your message as text.",
+			createCancelKeyboard())
+		return
+	}
+
+	sessions.ClearState(userID)
 
 	// Save admin contact
-	err := saveAdminContact(userID, msg.Text)
+	contactID, err := saveAdminContact(userID, msg.Text)
 	if err != nil {
 		sendMessage(chatID, "❌ *Error*\n\nFailed to send message. Please try again.")
 		return
 	}
 
-	// Forward to admin group
-	adminMsg := fmt.Sprintf(
-		"📬 *ADMIN MESSAGE*\n──────────────\n\n"+
-			"👤 *From:* `%d`\n"+
-			"📱 *Username:* @%s\n"+
-			"🕐 *Time:* %s\n\n"+
-			"💭 *Message:*\n%s\n\n"+
-			"──────────────",
-		userID, msg.From.UserName, time.Now().Format("Jan 2, 3:04 PM"), msg.Text)
-
-	sendMessage(adminGroupID, adminMsg)
+	// Forward to admin group with reply/close buttons
+	forwardContactToAdmins(contactID, userID, msg.From.UserName, msg.Text, false)
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	// Notify user
 	sendMessageWithKeyboard(chatID,
 		"✅ *Message Sent*\n──────────────\n\n"+
 			"✨ *Your message has been delivered to admin team*\n\n"+
 			"📋 *Status:* Received\n"+
-			"⏳ *Response:* If needed\n\n"+
+			"⏳ *Response:* Admins can reply here anonymously\n\n"+
 			"──────────────\n"+
 			"Thank you for your feedback! 💖",
 		mainMenuKeyboard)
@@ -2055,7 +2046,10 @@ func createMainMenuKeyboard() tgbotapi.ReplyKeyboardMarkup {
 			tgbotapi.NewKeyboardButton("🎤 Voice Confession"),
 		),
 		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("📸 Photo Confession"),
 			tgbotapi.NewKeyboardButton("💝 Blind Connections"),
+		),
+		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("📞 Contact Admin"),
 		),
 		tgbotapi.NewKeyboardButtonRow(
@@ -2075,6 +2069,7 @@ func createConfessionTypeKeyboard() tgbotapi.ReplyKeyboardMarkup {
 			tgbotapi.NewKeyboardButton("🎤 Voice Confession"),
 		),
 		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("📸 Photo Confession"),
 			tgbotapi.NewKeyboardButton("❌ Cancel"),
 		),
 	)
@@ -2221,7 +2216,7 @@ func sendMessage(chatID int64, text string) {
 	msg.DisableWebPagePreview = true
 
 	// Check if we have an active keyboard for this chat
-	if keyboard, ok := activeKeyboards[chatID]; ok {
+	if keyboard, ok := sessions.Keyboard(chatID); ok {
 		msg.ReplyMarkup = keyboard
 	} else if chatID > 0 {
 		// Default to main menu for private chats
@@ -2241,7 +2236,7 @@ func sendMessageWithKeyboard(chatID int64, text string, keyboard tgbotapi.ReplyK
 	msg.ReplyMarkup = keyboard
 
 	// Store this keyboard as active for the chat
-	activeKeyboards[chatID] = keyboard
+	sessions.SetKeyboard(chatID, keyboard)
 
 	_, err := bot.Send(msg)
 	if err != nil {
@@ -2272,7 +2267,7 @@ func sendEnhancedWelcomeMessage(chatID int64) {
 *Express yourself. Anonymously.* 👇`
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendMessageWithKeyboard(chatID, welcomeText, mainMenuKeyboard)
 }
 
@@ -2313,8 +2308,11 @@ func getUserGender(userID int64) (string, error) {
 }
 
 func isBanned(userID int64) bool {
+	// Expired temporary bans stop counting even before the cleanup lifts them
 	var banned int
-	err := db.QueryRow("SELECT banned FROM users WHERE user_id = ?", userID).Scan(&banned)
+	err := db.QueryRow(`
+		SELECT banned FROM users
+		WHERE user_id = ? AND (ban_until IS NULL OR ban_until > datetime('now'))`, userID).Scan(&banned)
 	if err != nil && err != sql.ErrNoRows {
 		log.Println("Error checking ban:", err)
 	}
@@ -2347,24 +2345,30 @@ func saveVoiceConfession(userID int64, voiceID string) (int64, error) {
 	return confessionID, nil
 }
 
-func sendTextToAdmin(confessionID int, userID int64, text string) {
+func sendTextToAdmin(confessionID int, userID int64, text string, moderation ModerationResult) {
 	adminText := fmt.Sprintf(
 		"📝 *NEW TEXT CONFESSION* #%d\n──────────────\n\n"+
 			"💭 *Content:*\n%s\n\n"+
+			"%s"+
 			"──────────────\n"+
 			"👤 *Sender ID:* `%d`\n"+
 			"🕐 *Time:* %s\n"+
 			"📊 *Type:* Text Confession\n"+
 			"──────────────",
-		confessionID, text, userID, time.Now().Format("Jan 2, 3:04 PM"))
+		confessionID, text, moderationFlagsText(moderation), userID, time.Now().Format("Jan 2, 3:04 PM"))
 
 	adminMsg := tgbotapi.NewMessage(adminGroupID, adminText)
 	adminMsg.ParseMode = "Markdown"
 	adminMsg.ReplyMarkup = createAdminApprovalKeyboard(confessionID, "text")
-	bot.Send(adminMsg)
+	sent, err := bot.Send(adminMsg)
+	if err != nil {
+		log.Println("Error sending confession to admins:", err)
+		return
+	}
+	saveAdminMessageID(confessionID, sent.MessageID)
 }
 
-func sendVoiceToAdmin(confessionID int, userID int64, voiceID string, duration int) {
+func sendVoiceToAdmin(confessionID int, userID int64, voice tgbotapi.RequestFileData, duration int) (tgbotapi.Message, error) {
 	adminText := fmt.Sprintf(
 		"🎤 *NEW VOICE CONFESSION* #%d\n──────────────\n\n"+
 			"⏱️ *Duration:* %d seconds\n"+
@@ -2377,12 +2381,12 @@ func sendVoiceToAdmin(confessionID int, userID int64, voiceID string, duration i
 			"──────────────",
 		confessionID, duration, userID, time.Now().Format("Jan 2, 3:04 PM"))
 
-	// Send voice with caption - using the ANONYMIZED voice ID
-	voiceMsg := tgbotapi.NewVoice(adminGroupID, tgbotapi.FileID(voiceID))
+	// Send voice with caption - using the ANONYMIZED voice
+	voiceMsg := tgbotapi.NewVoice(adminGroupID, voice)
 	voiceMsg.Caption = adminText
 	voiceMsg.ParseMode = "Markdown"
 	voiceMsg.ReplyMarkup = createAdminApprovalKeyboard(confessionID, "voice")
-	bot.Send(voiceMsg)
+	return bot.Send(voiceMsg)
 }
 
 func saveBlindProfile(userID int64, data map[string]interface{}) error {
@@ -2468,12 +2472,14 @@ func showBlindProfile(userID int64, chatID int64) {
 *PREFERENCES:*
 👫 *Looking for:* %s
 🎂 *Age Range:* %d - %d years
+⚖️ *Matching:* %s
 
 ─────────────────────────────
 *PROFILE INFO:*
 ✅ *Status:* Verified
 📅 *Created:* %s
-🔒 *Note:* Profile cannot be changed
+✏️ *Edit:* /editprofile
+🗑️ *Delete:* /deleteprofile
 
 ─────────────────────────────
 *Ready to find your match?*
@@ -2485,51 +2491,42 @@ Use /blind to start searching!`,
 		prefGenderText,
 		profile.PrefAgeMin,
 		profile.PrefAgeMax,
+		matchingPolicyText(),
 		profile.CreatedAt.Format("Jan 2, 2006"))
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendMessageWithKeyboard(chatID, profileText, mainMenuKeyboard)
 }
 
-func saveAdminContact(userID int64, message string) error {
-	_, err := db.Exec(`
-		INSERT INTO admin_contacts (user_id, message, status)
-		VALUES (?, ?, 'pending')`,
+func saveAdminContact(userID int64, message string) (int64, error) {
+	result, err := db.Exec(`
+		INSERT INTO admin_contacts (user_id, message, status, updated_at)
+		VALUES (?, ?, 'pending', datetime('now'))`,
 		userID, message)
 
 	if err != nil {
 		log.Println("Error saving admin contact:", err)
-		return err
+		return 0, err
 	}
+	contactID, _ := result.LastInsertId()
 
-	// Update user's last contact time
-	_, err = db.Exec(`
-		UPDATE users SET admin_contact_allowed = 0 
-		WHERE user_id = ?`, userID)
-
-	return err
-}
-
-func canContactAdmin(userID int64) bool {
-	var allowed int
-	err := db.QueryRow(`
-		SELECT admin_contact_allowed FROM users 
-		WHERE user_id = ?`, userID).Scan(&allowed)
-
-	if err != nil {
-		return true
+	// The first message opens the thread
+	if err := addContactMessage(contactID, "user", 0, message); err != nil {
+		log.Println("Error saving contact message:", err)
 	}
 
-	return allowed == 1
+	recordAction(userID, ActionAdminContact)
+
+	return contactID, nil
 }
 
 // ----------------- BLIND CHAT MESSAGE HANDLING -----------------
 func handleBlindChatMessage(senderID int64, partner BlindChatPair, msg *tgbotapi.Message) {
 	// Always keep romantic keyboard for blind chats
 	romanticKeyboard := createRomanticChatKeyboard()
-	activeKeyboards[senderID] = romanticKeyboard
-	activeKeyboards[partner.PartnerID] = romanticKeyboard
+	sessions.SetKeyboard(senderID, romanticKeyboard)
+	sessions.SetKeyboard(partner.PartnerID, romanticKeyboard)
 
 	// Forward text messages with partner's username
 	if msg.Text != "" {
@@ -2544,9 +2541,9 @@ func handleBlindChatMessage(senderID int64, partner BlindChatPair, msg *tgbotapi
 
 	// Forward voice messages (allowed for verification)
 	if msg.Voice != nil {
-		if msg.Voice.Duration > 60 {
+		if msg.Voice.Duration > cfg.BlindVoiceMaxSeconds {
 			sendMessageWithKeyboard(senderID,
-				"⏱️ *Voice too long*\n\nKeep voice messages under 1 minute.",
+				fmt.Sprintf("⏱️ *Voice too long*\n\nKeep voice messages under %s.", formatSeconds(cfg.BlindVoiceMaxSeconds)),
 				romanticKeyboard)
 			return
 		}
@@ -2554,33 +2551,27 @@ func handleBlindChatMessage(senderID int64, partner BlindChatPair, msg *tgbotapi
 		// Get sender's gender for voice anonymization
 		gender, err := getUserGender(senderID)
 		if err == nil {
-			// Anonymize the voice
-			anonymizedVoiceID, err := anonymizeVoice(msg.Voice.FileID, gender)
+			// Anonymize in the background; the worker delivers it to the partner
+			_, err = enqueueVoiceJob(VoiceJob{
+				Kind:      "blind",
+				UserID:    senderID,
+				ChatID:    senderID,
+				PartnerID: partner.PartnerID,
+				FileID:    msg.Voice.FileID,
+				Gender:    gender,
+				Duration:  msg.Voice.Duration,
+			})
 			if err == nil {
-				voiceMsg := tgbotapi.NewVoice(partner.PartnerID, tgbotapi.FileID(anonymizedVoiceID))
-				voiceMsg.Caption = fmt.Sprintf("🎤 *Voice from %s*", partner.PartnerUsername)
-				voiceMsg.ReplyMarkup = romanticKeyboard
-				bot.Send(voiceMsg)
-			} else {
-				log.Printf("Voice anonymization failed: %v", err)
-				// If anonymization fails, send original voice
-				voiceMsg := tgbotapi.NewVoice(partner.PartnerID, tgbotapi.FileID(msg.Voice.FileID))
-				voiceMsg.Caption = fmt.Sprintf("🎤 *Voice from %s*", partner.PartnerUsername)
-				voiceMsg.ReplyMarkup = romanticKeyboard
-				bot.Send(voiceMsg)
+				return
 			}
-		} else {
-			// If can't get gender, send original voice
-			voiceMsg := tgbotapi.NewVoice(partner.PartnerID, tgbotapi.FileID(msg.Voice.FileID))
-			voiceMsg.Caption = fmt.Sprintf("🎤 *Voice from %s*", partner.PartnerUsername)
-			voiceMsg.ReplyMarkup = romanticKeyboard
-			bot.Send(voiceMsg)
+			log.Printf("Failed to queue blind chat voice: %v", err)
 		}
 
-		// Send tip to partner
-		sendMessageWithKeyboard(partner.PartnerID,
-			"💡 *Tip:* Voice messages are anonymized for privacy using Rubber Band!",
-			romanticKeyboard)
+		// If can't anonymize, send original voice
+		voiceMsg := tgbotapi.NewVoice(partner.PartnerID, tgbotapi.FileID(msg.Voice.FileID))
+		voiceMsg.Caption = fmt.Sprintf("🎤 *Voice from %s*", partner.PartnerUsername)
+		voiceMsg.ReplyMarkup = romanticKeyboard
+		bot.Send(voiceMsg)
 		return
 	}
 
@@ -2615,9 +2606,21 @@ func handleBlindChatMessage(senderID int64, partner BlindChatPair, msg *tgbotapi
 	}
 }
 
+// formatSeconds renders a duration limit like "2 minutes" or "45 seconds"
+func formatSeconds(seconds int) string {
+	if seconds%60 == 0 {
+		minutes := seconds / 60
+		if minutes == 1 {
+			return "1 minute"
+		}
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	return fmt.Sprintf("%d seconds", seconds)
+}
+
 func isButtonText(text string) bool {
 	buttonTexts := []string{
-		"📝 Text Confession", "🎤 Voice Confession", "💝 Blind Connections",
+		"📝 Text Confession", "🎤 Voice Confession", "📸 Photo Confession", "💝 Blind Connections",
 		"📞 Contact Admin", "📊 My Stats", "📜 Guidelines", "⭐ Rate Us",
 		"❌ Cancel Search", "🏠 Main Menu", "💔 End Chat", "🚨 Report User",
 		"❤️ Send Heart", "😊 Send Smile", "💬 Send Voice", "📸 Send Photo",
@@ -2665,6 +2668,39 @@ func handleCallback(cb *tgbotapi.CallbackQuery) {
 	case "report_reason":
 		handleReportReasonCallback(parts, cb)
 
+	case "editprofile":
+		handleEditProfileCallback(parts, cb)
+
+	case "deleteprofile":
+		handleDeleteProfileCallback(parts, cb)
+
+	case "appeal":
+		handleAppealCallback(parts, cb)
+
+	case "contact":
+		handleContactCallback(parts, cb)
+
+	case "comment":
+		handleCommentCallback(parts, cb)
+
+	case "notify":
+		handleNotifyCallback(parts, cb)
+
+	case "photo":
+		handlePhotoCallback(parts, cb)
+
+	case "draft":
+		handleDraftCallback(parts, cb)
+
+	case "publish":
+		handlePublishCallback(parts, cb)
+
+	case "mine":
+		handleMineCallback(parts, cb)
+
+	case "reject_reason":
+		handleRejectReasonCallback(parts, cb)
+
 	default:
 		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown action"))
 	}
@@ -2681,99 +2717,65 @@ func handleApproveCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 
 	// Get confession from database
 	var confession Confession
-	var voiceID, text string
-
-	if confessionType == "voice" {
-		err := db.QueryRow(`
-			SELECT id, user_id, voice_id, date 
-			FROM confessions WHERE id = ?`, confessionID).Scan(
-			&confession.ID, &confession.UserID, &voiceID, &confession.Date)
-		if err != nil {
-			log.Println("Error getting voice confession:", err)
-			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
-			return
-		}
-	} else {
-		err := db.QueryRow(`
-			SELECT id, user_id, text, date 
-			FROM confessions WHERE id = ?`, confessionID).Scan(
-			&confession.ID, &confession.UserID, &text, &confession.Date)
-		if err != nil {
-			log.Println("Error getting text confession:", err)
-			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
-			return
-		}
-	}
-
-	// Update confession status
-	_, err := db.Exec(`
-		UPDATE confessions 
-		SET approved = 1, posted_at = datetime('now')
-		WHERE id = ?`, confessionID)
+	err := db.QueryRow(`
+		SELECT id, user_id, date 
+		FROM confessions WHERE id = ?`, confessionID).Scan(
+		&confession.ID, &confession.UserID, &confession.Date)
 	if err != nil {
-		log.Println("Error approving confession:", err)
+		log.Println("Error getting confession:", err)
 		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
 		return
 	}
 
-	// Post to channel with FROSTED MIRROR style
-	channelMsgID, err := postFrostedMirrorConfession(confessionID, confessionType, text, voiceID)
+	// Approved confessions wait for a publishing slot
+	queued, err := queueConfession(confessionID)
 	if err != nil {
-		log.Println("Error posting confession:", err)
+		log.Println("Error approving confession:", err)
 		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
 		return
 	}
-
-	// Save channel message ID
-	_, err = db.Exec("UPDATE confessions SET channel_message_id = ? WHERE id = ?", channelMsgID, confessionID)
-	if err != nil {
-		log.Println("Error saving channel message ID:", err)
+	if !queued {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already reviewed"))
+		return
 	}
 
-	// Update admin message
-	statusText := "✅"
-	if confessionType == "voice" {
-		statusText = "✅ *VOICE APPROVED*"
-	} else {
-		statusText = "✅ *TEXT APPROVED*"
+	if cfg.PublishInterval.Duration == 0 {
+		// No schedule: post to channel with FROSTED MIRROR style right away
+		if err := publishConfession(confessionID); err != nil {
+			log.Println("Error posting confession:", err)
+			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+			return
+		}
+
+		editReviewCard(cb.Message.MessageID, confessionType, publishedAdminText(confessionType, confessionID, confession.UserID),
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+
+		bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Published"))
+		return
 	}
 
-	editMsg := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID,
-		fmt.Sprintf("%s #%d\n──────────────\n\n"+
-			"✨ *Published with frosted mirror style*\n\n"+
-			"👤 *Sender ID:* `%d`\n"+
-			"✅ *Status:* Posted to channel\n"+
-			"🎨 *Style:* Minimal, professional\n"+
-			"💬 *System:* Reaction & comment enabled\n"+
-			"🕐 *Time:* %s",
-			statusText, confessionID, confession.UserID, time.Now().Format("3:04 PM")))
-	editMsg.ParseMode = "Markdown"
-	bot.Send(editMsg)
+	position, ok := queuePosition(confessionID)
+	if !ok {
+		// The publish routine took it already and tells the author itself
+		editReviewCard(cb.Message.MessageID, confessionType, publishedAdminText(confessionType, confessionID, confession.UserID),
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Published"))
+		return
+	}
+	times := nextPublishTimes(position)
+	eta := times[position-1]
 
-	// Remove buttons
-	editMarkup := tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID,
-		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
-	bot.Send(editMarkup)
+	editReviewCard(cb.Message.MessageID, confessionType, queuedAdminText(confessionID, confession.UserID, position, eta),
+		createQueuedAdminKeyboard(confessionID))
 
 	// Notify user
-	userMsg := tgbotapi.NewMessage(confession.UserID,
-		fmt.Sprintf("✅ *CONFESSION PUBLISHED*\n──────────────\n\n"+
-			"✨ *Your %s confession is now live*\n\n"+
-			"📜 *Confession ID:* #%d\n"+
-			"✅ *Status:* Published anonymously\n"+
-			"🎨 *Style:* Frosted mirror presentation\n"+
-			"💫 *People can react with emotional responses*\n"+
-			"💬 *Comments:* Viewable via comment button\n\n"+
-			"──────────────\n"+
-			"*Thank you for sharing.*",
-			confessionType, confessionID))
-	userMsg.ParseMode = "Markdown"
-	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[confession.UserID] = mainMenuKeyboard
-	userMsg.ReplyMarkup = mainMenuKeyboard
-	bot.Send(userMsg)
+	sendMessage(confession.UserID, fmt.Sprintf("✅ *CONFESSION APPROVED*\n──────────────\n\n"+
+		"📜 *Confession ID:* #%d\n"+
+		"🗓️ *Expected in the channel:* %s\n\n"+
+		"We space posts out through the day. You'll get a message when it's live.",
+		confessionID, formatPublishTime(eta)))
 
-	bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Published"))
+	bot.Send(tgbotapi.NewCallback(cb.ID, "⏳ Queued"))
 }
 
 func handleRejectCallback(parts []string, cb *tgbotapi.CallbackQuery) {
@@ -2785,63 +2787,24 @@ func handleRejectCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 	confessionID, _ := strconv.Atoi(parts[1])
 
 	// Get confession
-	var confession Confession
-	err := db.QueryRow(`
-		SELECT id, user_id, type, date 
-		FROM confessions WHERE id = ?`, confessionID).Scan(
-		&confession.ID, &confession.UserID, &confession.Type, &confession.Date)
+	var status string
+	err := db.QueryRow("SELECT status FROM confessions WHERE id = ?", confessionID).Scan(&status)
 	if err != nil {
 		log.Println("Error getting confession:", err)
 		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
 		return
 	}
-
-	// Update confession status
-	_, err = db.Exec(`
-		UPDATE confessions 
-		SET approved = 0
-		WHERE id = ?`, confessionID)
-	if err != nil {
-		log.Println("Error rejecting confession:", err)
-		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+	if status != "pending" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already reviewed"))
 		return
 	}
 
-	// Update admin message
-	editMsg := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID,
-		fmt.Sprintf("❌ *REJECTED* #%d\n──────────────\n\n"+
-			"👤 *Sender ID:* `%d`\n"+
-			"❌ *Status:* Not approved\n"+
-			"📊 *Type:* %s\n"+
-			"🕐 *Time:* %s",
-			confessionID, confession.UserID, strings.Title(confession.Type),
-			time.Now().Format("3:04 PM")))
-	editMsg.ParseMode = "Markdown"
-	bot.Send(editMsg)
-
-	// Remove buttons
+	// Ask for a reason; the rejection happens once one is picked
 	editMarkup := tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID,
-		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		createRejectReasonKeyboard(confessionID))
 	bot.Send(editMarkup)
 
-	// Notify user
-	userMsg := tgbotapi.NewMessage(confession.UserID,
-		fmt.Sprintf("❌ *CONFESSION REVIEWED*\n──────────────\n\n"+
-			"📋 *Status:* Not Approved\n\n"+
-			"⚠️ *Reason:* Content didn't meet guidelines\n\n"+
-			"💡 *Tips:*\n"+
-			"• Keep it respectful\n"+
-			"• Be positive\n"+
-			"• Avoid personal info\n\n"+
-			"──────────────\n"+
-			"✨ *You can try again now!*"))
-	userMsg.ParseMode = "Markdown"
-	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[confession.UserID] = mainMenuKeyboard
-	userMsg.ReplyMarkup = mainMenuKeyboard
-	bot.Send(userMsg)
-
-	bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Rejected"))
+	bot.Send(tgbotapi.NewCallback(cb.ID, "📋 Pick a reason"))
 }
 
 func handleBanCallback(parts []string, cb *tgbotapi.CallbackQuery) {
@@ -2864,8 +2827,8 @@ func handleBanCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 		return
 	}
 
-	// Ban user
-	_, err = db.Exec("UPDATE users SET banned = 1 WHERE user_id = ?", confession.UserID)
+	// Ban user; the strike ledger notifies them
+	err = applyStrike(confession.UserID, StrikePermBan, fmt.Sprintf("Confession #%d", confessionID), "admin", cb.From.ID, 0)
 	if err != nil {
 		log.Println("Error banning user:", err)
 	}
@@ -2873,7 +2836,7 @@ func handleBanCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 	// Update confession status
 	_, err = db.Exec(`
 		UPDATE confessions 
-		SET approved = 0
+		SET approved = 0, status = 'rejected'
 		WHERE id = ?`, confessionID)
 	if err != nil {
 		log.Println("Error rejecting confession:", err)
@@ -2898,18 +2861,6 @@ func handleBanCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
 	bot.Send(editMarkup)
 
-	// Notify user
-	userMsg := tgbotapi.NewMessage(confession.UserID,
-		"🚫 *ACCOUNT BANNED*\n──────────────\n\n"+
-			"⛔ *Your account has been banned.*\n\n"+
-			"📋 *Reason:* Violation of community guidelines\n"+
-			"⏳ *Duration:* Permanent\n\n"+
-			"📞 *Contact admin for appeal*\n\n"+
-			"──────────────\n"+
-			"⚠️ *You can no longer use this bot.*")
-	userMsg.ParseMode = "Markdown"
-	bot.Send(userMsg)
-
 	bot.Send(tgbotapi.NewCallback(cb.ID, "✅ User banned"))
 }
 
@@ -2969,11 +2920,11 @@ func handleReactionCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 			INSERT INTO confession_reactions (confession_id, user_id, emoji)
 			VALUES (?, ?, ?)`,
 			confessionID, userID, emoji)
+		checkReactionMilestones(confessionID, emoji, userID)
 	}
 
 	// Get comment count
-	var commentCount int
-	db.QueryRow("SELECT COUNT(*) FROM confession_comments WHERE confession_id = ?", confessionID).Scan(&commentCount)
+	commentCount := visibleCommentCount(confessionID)
 
 	// Update the channel message with new counts
 	updateChannelButtons(confessionID, cb.Message.MessageID, commentCount)
@@ -2992,7 +2943,7 @@ func handleReportReasonCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 	reporterID := cb.From.ID
 
 	// Get reporter's current partner to verify they're in chat
-	reporterPartner, ok := pairs[reporterID]
+	reporterPartner, ok := sessions.Partner(reporterID)
 	if !ok {
 		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ You're not in a chat"))
 		return
@@ -3004,9 +2955,6 @@ func handleReportReasonCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 		return
 	}
 
-	// Increment report count
-	reports[reportedID]++
-
 	// Save report to database
 	_, err := db.Exec(`
 		INSERT INTO reports (reporter_id, reported_id, reason, context)
@@ -3019,6 +2967,9 @@ func handleReportReasonCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 		return
 	}
 
+	// Each strike needs a fresh set of reports
+	reportCount := getReportsSinceStrike(reportedID)
+
 	// Get reported user's username
 	var reportedUsername string
 	db.QueryRow("SELECT username FROM users WHERE user_id = ?", reportedID).Scan(&reportedUsername)
@@ -3031,28 +2982,29 @@ func handleReportReasonCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 		fmt.Sprintf("✅ *Report Submitted*\n──────────────\n\n"+
 			"📋 *Reported:* %s\n"+
 			"📝 *Reason:* %s\n"+
-			"📊 *Reports against user:* %d/3\n\n"+
-			"⚠️ *User will be banned after 3 reports*\n\n"+
+			"📊 *Reports against user:* %d/%d\n\n"+
+			"⚠️ *Moderators step in after %d reports*\n\n"+
 			"──────────────\n"+
 			"Thank you for keeping our community safe! 💖",
-			reportedUsername, reason, reports[reportedID]))
+			reportedUsername, reason, reportCount, cfg.ReportBanThreshold, cfg.ReportBanThreshold))
 
-	// Check if user should be banned
-	if reports[reportedID] >= 3 {
-		banUser(reportedID)
-		endBlindChatForUser(reportedID)
+	// Enough reports earn the next strike on the ladder
+	if reportCount >= cfg.ReportBanThreshold {
+		kind, err := issueStrike(reportedID, fmt.Sprintf("%d+ blind chat reports", cfg.ReportBanThreshold), "reports", 0)
+		if err != nil {
+			log.Println("Error issuing strike:", err)
+		}
 
 		// Notify admin
 		sendMessage(adminGroupID,
-			fmt.Sprintf("🚫 *USER AUTO-BANNED*\n──────────────\n\n"+
+			fmt.Sprintf("🚨 *AUTOMATIC STRIKE*\n──────────────\n\n"+
 				"👤 *User ID:* `%d`\n"+
 				"👤 *Username:* %s\n"+
-				"📋 *Reason:* 3+ blind chat reports\n"+
+				"⚖️ *Action:* %s\n"+
+				"📋 *Reason:* %d+ blind chat reports\n"+
 				"🚨 *Last Report:* %s\n"+
-				"🕐 *Time:* %s\n\n"+
-				"──────────────\n"+
-				"User has been automatically banned.",
-				reportedID, reportedUsername, reason, time.Now().Format("3:04 PM")))
+				"🕐 *Time:* %s",
+				reportedID, escapeMarkdown(reportedUsername), strikeKindText(kind), cfg.ReportBanThreshold, reason, time.Now().Format("3:04 PM")))
 	}
 
 	// Edit original message
@@ -3064,122 +3016,73 @@ func handleReportReasonCallback(parts []string, cb *tgbotapi.CallbackQuery) {
 	bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Report submitted"))
 }
 
-func endBlindChatForUser(userID int64) {
-	if partner, ok := pairs[userID]; ok {
-		delete(pairs, userID)
-		delete(pairs, partner.PartnerID)
-		delete(activeKeyboards, userID)
-		delete(activeKeyboards, partner.PartnerID)
+func endBlindChatForUser(userID int64, reason string) {
+	if partner, ok := sessions.Unpair(userID); ok {
+		deleteBlindPair(userID, partner.PartnerID)
+		sessions.ClearKeyboard(userID)
+		sessions.ClearKeyboard(partner.PartnerID)
 
 		mainMenuKeyboard := createMainMenuKeyboard()
-		activeKeyboards[partner.PartnerID] = mainMenuKeyboard
+		sessions.SetKeyboard(partner.PartnerID, mainMenuKeyboard)
 		sendMessageWithKeyboard(partner.PartnerID,
 			fmt.Sprintf("⚠️ *Chat Ended*\n──────────────\n\n"+
 				"💬 *%s has been removed*\n\n"+
-				"🔒 *Reason:* Multiple user reports\n"+
-				"✨ *You can find a new partner with /blind*", partner.PartnerUsername),
+				"🔒 *Reason:* %s\n"+
+				"✨ *You can find a new partner with /blind*", partner.PartnerUsername, reason),
 			mainMenuKeyboard)
 	}
 }
 
-func banUser(userID int64) {
-	db.Exec("UPDATE users SET banned = 1 WHERE user_id = ?", userID)
-}
-
 // ----------------- CLEANUP ROUTINES -----------------
 func cleanupRoutine() {
-	ticker := time.NewTicker(10 * time.Minute)
+	ticker := time.NewTicker(cfg.CleanupInterval.Duration)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		cleanupOldStates()
 		cleanupWaitingUsers()
-		cleanupExpiredContacts()
+		cleanupRateLimitEvents()
 		cleanupOldCommentWaiting()
 		cleanupStaleKeyboards()
+		cleanupExpiredBans()
+		cleanupOldDrafts()
 	}
 }
 
 func cleanupOldStates() {
-	now := time.Now()
-	for userID, state := range userStates {
-		if now.Sub(state.LastActive) > 30*time.Minute {
-			delete(userStates, userID)
-			delete(confessionWaiting, userID)
-		}
-	}
+	sessions.ExpireStates(cfg.StateTimeout.Duration)
 }
 
 func cleanupWaitingUsers() {
 	// Remove users who have been waiting too long
-	if waitingUser != 0 {
-		if state, exists := userStates[waitingUser]; exists {
-			if time.Since(state.LastActive) > 30*time.Minute {
-				waitingUser = 0
-			}
-		} else {
-			// If no state exists, assume they left
-			waitingUser = 0
-		}
+	for _, expired := range sessions.ExpireWaiting(cfg.StateTimeout.Duration) {
+		deleteQueueEntry(expired)
 	}
 }
 
-func cleanupExpiredContacts() {
-	// Allow users to contact admin again after 7 days
-	db.Exec(`
-		UPDATE users 
-		SET admin_contact_allowed = 1 
-		WHERE user_id IN (
-			SELECT user_id FROM admin_contacts 
-			WHERE created_at < datetime('now', '-7 days')
-		)`)
-}
-
 func cleanupOldCommentWaiting() {
-	// Clean up old comment waiting states (older than 10 minutes)
-	now := time.Now()
-	for userID, data := range commentWaiting {
-		if state, exists := userStates[userID]; exists {
-			if now.Sub(state.LastActive) > 10*time.Minute {
-				// Notify user
-				if data.WaitingForComment {
-					sendMessage(userID,
-						"⏰ *Comment session expired*\n\n"+
-							"Your comment session has timed out. Please click the comment button again if you still want to comment.")
-				}
-				delete(commentWaiting, userID)
-			}
-		} else {
-			// If no user state exists, remove
-			delete(commentWaiting, userID)
-		}
+	// Clean up old comment waiting states and notify users who were mid-comment
+	for _, userID := range sessions.ExpireComments(cfg.CommentTimeout.Duration) {
+		sendMessage(userID,
+			"⏰ *Comment session expired*\n\n"+
+				"Your comment session has timed out. Please click the comment button again if you still want to comment.")
 	}
 }
 
 func cleanupStaleKeyboards() {
 	// Clean up keyboards for users who haven't been active
-	now := time.Now()
-	for userID := range activeKeyboards {
-		if state, exists := userStates[userID]; exists {
-			if now.Sub(state.LastActive) > 30*time.Minute {
-				delete(activeKeyboards, userID)
-			}
-		} else {
-			// If no user state exists, remove after 1 hour
-			delete(activeKeyboards, userID)
-		}
-	}
+	sessions.ExpireKeyboards(cfg.StateTimeout.Duration)
 }
 
 // ----------------- MESSAGE HELPERS -----------------
 func sendEnhancedHelpMessage(chatID int64) {
-	helpText := `📚 *FROSTED MIRROR HELP GUIDE*
+	helpText := fmt.Sprintf(`📚 *FROSTED MIRROR HELP GUIDE*
 ─────────────────────────────
 
 ✨ *CONFESSION SYSTEM*
 📝 *Text Confessions*
 • Click "Text Confession" button
-• Write anonymously (10-2000 chars)
+• Write anonymously (%d-%d chars)
 • Reviewed by admins
 • Posted with frosted mirror style
 
@@ -3187,14 +3090,20 @@ func sendEnhancedHelpMessage(chatID int64) {
 • Click "Voice Confession" button
 • Rubber Band voice anonymization
 • Gender-specific voice conversion
-• 100% untraceable to your real voice
-• Speed unchanged (100% natural)
+• 100%% untraceable to your real voice
+• Speed unchanged (100%% natural)
+
+📸 *Photo Confessions*
+• Click "Photo Confession" button
+• Location, camera and time data removed
+• Optional blur before submitting
+• Caption up to %d chars
 
 ─────────────────────────────
 💝 *BLIND CONNECTION SYSTEM*
 • Permanent gender selection required
 • Username collection during registration
-• Opposite gender matching only
+• %s
 • Voice messages anonymized with Rubber Band
 • Safe, respectful environment
 • Report fake profiles
@@ -3204,9 +3113,17 @@ func sendEnhancedHelpMessage(chatID int64) {
 • Click "💬 Comment" URL button in channel
 • Opens bot in private chat
 • Anonymous commenting
+• Reply to a comment from its 💬 thread
+• Get notified of replies (/replies off to stop)
 • Only comment count updates in channel
 • Comments stored privately
 
+─────────────────────────────
+📜 *YOUR CONFESSIONS*
+• /myconfessions lists yours with their status
+• Withdraw one while it's in review
+• Or delete a published one from the channel
+
 ─────────────────────────────
 📊 *REACTION SYSTEM*
 • ❤️ Like
@@ -3215,22 +3132,24 @@ func sendEnhancedHelpMessage(chatID int64) {
 • 🌫️ Confused
 • 🌙 Relate
 • Click to react, click again to remove
+• /notifications on for digests of activity on your confessions
 
 ─────────────────────────────
 📞 *ADMIN CONTACT*
-• One message per week
+• New messages %s every %s
 • Direct to admin team
-• No back-and-forth
+• Admins reply anonymously in a thread
 • For feedback/questions
 
 ─────────────────────────────
 *Need more help?*
 Use /contact_admin to message us directly.
 
-*Enjoy the minimal, professional experience!* 🤫✨`
+*Enjoy the minimal, professional experience!* 🤫✨`, cfg.ConfessionMinLength, cfg.ConfessionMaxLength, photoCaptionLimit(), matchingPolicyText(),
+		timesText(cfg.RateLimits[ActionAdminContact].Max), formatWait(cfg.RateLimits[ActionAdminContact].Window.Duration))
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendMessageWithKeyboard(chatID, helpText, mainMenuKeyboard)
 }
 
@@ -3267,7 +3186,7 @@ func sendEnhancedStatusMessage(userID, chatID int64) {
 
 	// Check if in chat
 	inChat := "❌ No"
-	if _, ok := pairs[userID]; ok {
+	if sessions.InPair(userID) {
 		inChat = "✅ Yes"
 	}
 
@@ -3294,7 +3213,7 @@ func sendEnhancedStatusMessage(userID, chatID int64) {
 • Total: %d confessions
 • Voice: %d voice notes
 • Approved: %d published
-• Rate Limit: ✅ Unlimited
+%s
 
 *💬 ENGAGEMENT*
 • Comments: %d comments
@@ -3303,7 +3222,7 @@ func sendEnhancedStatusMessage(userID, chatID int64) {
 
 *💝 BLIND CONNECTIONS*
 • Profile: %s
-• Reports: %d/3
+• Reports: %d/%d
 
 *📞 ADMIN CONTACT*
 • Status: %s
@@ -3324,7 +3243,7 @@ func sendEnhancedStatusMessage(userID, chatID int64) {
 				return "✅ Active"
 			}
 		}(),
-		confessionCount, voiceCount, approvedCount,
+		confessionCount, voiceCount, approvedCount, confessionQuotaText(userID),
 		commentCount, reactionCount, inChat,
 		func() string {
 			if hasProfile {
@@ -3333,22 +3252,21 @@ func sendEnhancedStatusMessage(userID, chatID int64) {
 				return "❌ Not set"
 			}
 		}(),
-		reports[userID],
+		getReportCount(userID), cfg.ReportBanThreshold,
 		func() string {
-			if canContactAdmin(userID) {
-				return "✅ Allowed"
-			} else {
-				return "⏳ Weekly limit"
+			if allowed, wait := checkRateLimit(userID, ActionAdminContact); !allowed {
+				return "⏳ Available in " + formatWait(wait)
 			}
+			return "✅ Allowed"
 		}())
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendMessageWithKeyboard(chatID, statusText, mainMenuKeyboard)
 }
 
 func sendEnhancedRulesMessage(chatID int64) {
-	rulesText := `📜 *COMMUNITY GUIDELINES*
+	rulesText := fmt.Sprintf(`📜 *COMMUNITY GUIDELINES*
 ─────────────────────────────
 
 *1. RESPECT & KINDNESS* 🙏
@@ -3365,7 +3283,7 @@ func sendEnhancedRulesMessage(chatID int64) {
 *3. APPROPRIATE CONTENT* ✅
 • Confessions should be respectful
 • No explicit or harmful content
-• Voice confessions max 2 minutes
+• Voice confessions max %s
 
 *4. COMMENT SYSTEM* 💬
 • Comments are anonymous
@@ -3375,7 +3293,7 @@ func sendEnhancedRulesMessage(chatID int64) {
 
 *5. BLIND CONNECTION ETIQUETTE* 💝
 • Complete profile honestly with username
-• Opposite gender matching only
+• %s
 • Respect gender preferences
 • Report fake profiles immediately
 • End chats respectfully
@@ -3383,7 +3301,7 @@ func sendEnhancedRulesMessage(chatID int64) {
 ─────────────────────────────
 *CONSEQUENCES OF VIOLATIONS:*
 1️⃣ First offense: Warning
-2️⃣ Second offense: Temporary ban
+2️⃣ Second offense: Temporary ban (%s)
 3️⃣ Third offense: Permanent ban
 🔴 Fake profiles: Immediate ban
 
@@ -3393,10 +3311,10 @@ Create a safe, anonymous space for
 expression, connection, and community
 building with minimal, professional design.
 
-*THANK YOU FOR BEING AMAZING!* ✨🤫`
+*THANK YOU FOR BEING AMAZING!* ✨🤫`, formatSeconds(cfg.VoiceConfessionMaxSeconds), matchingPolicyText(), formatBanDuration(cfg.TempBanDuration.Duration))
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendMessageWithKeyboard(chatID, rulesText, mainMenuKeyboard)
 }
 
@@ -3430,6 +3348,6 @@ Help grow our community!
 *THANK YOU FOR USING OUR BOT!* 🤫✨`
 
 	mainMenuKeyboard := createMainMenuKeyboard()
-	activeKeyboards[chatID] = mainMenuKeyboard
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
 	sendMessageWithKeyboard(chatID, feedbackText, mainMenuKeyboard)
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------- MATCHMAKING POOL -----------------
+
+// Score weights; they add up to 100 so a perfect, long-waiting match scores 100.
+const (
+	matchWeightAge         = 30.0
+	matchWeightYearOfStudy = 25.0
+	matchWeightCampusYears = 15.0
+	matchWeightWaitTime    = 30.0
+
+	// Waiting this long earns the full wait-time bonus
+	matchFullWaitBonus = 10 * time.Minute
+)
+
+type matchCandidate struct {
+	UserID int64
+	Score  float64
+}
+
+// findMatchingPartner scores every compatible user in the waiting pool and
+// claims the best one. It returns 0 when nobody suitable is waiting, and
+// taken is true when another searcher matched this user in the meantime.
+func findMatchingPartner(userID int64, profile *BlindProfile) (partnerID int64, taken bool) {
+	// A repeat search comes from a user who is in the pool themselves
+	searcherWaiting := sessions.IsWaiting(userID)
+
+	var candidates []matchCandidate
+
+	for candidateID, joinedAt := range sessions.WaitingUsers() {
+		// Don't match with yourself
+		if candidateID == userID || sessions.InPair(candidateID) {
+			continue
+		}
+
+		candidateProfile, err := getBlindProfile(candidateID)
+		if err != nil || !candidateProfile.ProfileSet {
+			continue
+		}
+
+		// Check mutual compatibility
+		if !areProfilesCompatible(profile, candidateProfile) {
+			continue
+		}
+
+		candidates = append(candidates, matchCandidate{
+			UserID: candidateID,
+			Score:  matchScore(profile, candidateProfile, time.Since(joinedAt)),
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	// Another searcher may claim a candidate first; fall through to the next best
+	for _, candidate := range candidates {
+		claimed, searcherFree := sessions.ClaimPartner(userID, searcherWaiting, candidate.UserID)
+		if !searcherFree {
+			return 0, true
+		}
+		if claimed {
+			log.Printf("💝 Matched %d with %d (score %.1f of %d candidates)", userID, candidate.UserID, candidate.Score, len(candidates))
+			return candidate.UserID, false
+		}
+	}
+	return 0, false
+}
+
+// matchScore rates how well two compatible profiles fit each other, plus a
+// bonus for how long the waiting user has been in the pool.
+func matchScore(p1, p2 *BlindProfile, waited time.Duration) float64 {
+	ageFit := (ageFitScore(p1, p2) + ageFitScore(p2, p1)) / 2
+
+	yearFit := 0.5
+	y1, ok1 := yearOfStudyNumber(p1.YearOfStudy)
+	y2, ok2 := yearOfStudyNumber(p2.YearOfStudy)
+	if ok1 && ok2 {
+		yearFit = 1 - math.Abs(float64(y1-y2))/4
+	}
+
+	campusDiff := math.Min(math.Abs(float64(p1.YearsOnCampus-p2.YearsOnCampus)), 5)
+	campusFit := 1 - campusDiff/5
+
+	waitFit := math.Min(waited.Seconds()/matchFullWaitBonus.Seconds(), 1)
+
+	return ageFit*matchWeightAge +
+		yearFit*matchWeightYearOfStudy +
+		campusFit*matchWeightCampusYears +
+		waitFit*matchWeightWaitTime
+}
+
+// ageFitScore is 1 when p2's age sits in the middle of p1's preferred range
+// and falls towards 0 at the edges.
+func ageFitScore(p1, p2 *BlindProfile) float64 {
+	mid := float64(p1.PrefAgeMin+p1.PrefAgeMax) / 2
+	halfWidth := float64(p1.PrefAgeMax-p1.PrefAgeMin)/2 + 1
+	return math.Max(0, 1-math.Abs(float64(p2.Age)-mid)/halfWidth)
+}
+
+// yearOfStudyNumber turns "1st Year" ... "5th+ Year" into 1 ... 5
+func yearOfStudyNumber(year string) (int, bool) {
+	digits := strings.TrimLeftFunc(year, func(r rune) bool { return r < '0' || r > '9' })
+	end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' })
+	if end > 0 {
+		digits = digits[:end]
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// ----------------- SCHEMA MIGRATIONS -----------------
+
+// Migration is one ordered, versioned schema change. Versions must be unique
+// and increasing; once a migration has shipped its statements must never change.
+type Migration struct {
+	Version    int
+	Name       string
+	Statements []string
+}
+
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		Statements: []string{
+			// Users table with gender
+			`CREATE TABLE IF NOT EXISTS users (
+				user_id INTEGER PRIMARY KEY,
+				username TEXT,
+				first_name TEXT,
+				last_name TEXT,
+				gender TEXT CHECK(gender IN ('male', 'female')),
+				banned INTEGER DEFAULT 0,
+				admin_contact_allowed INTEGER DEFAULT 1,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);`,
+
+			// Confessions table with channel message ID
+			`CREATE TABLE IF NOT EXISTS confessions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER,
+				text TEXT,
+				voice_id TEXT,
+				type TEXT DEFAULT 'text',
+				date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				approved INTEGER DEFAULT 0,
+				posted_at TIMESTAMP,
+				channel_message_id INTEGER,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			// Blind profiles table
+			`CREATE TABLE IF NOT EXISTS blind_profiles (
+				user_id INTEGER PRIMARY KEY,
+				gender TEXT CHECK(gender IN ('male', 'female')),
+				age INTEGER CHECK(age >= 18 AND age <= 50),
+				years_on_campus INTEGER CHECK(years_on_campus >= 0),
+				year_of_study TEXT,
+				pref_gender TEXT CHECK(pref_gender IN ('male', 'female', 'both')),
+				pref_age_min INTEGER CHECK(pref_age_min >= 18),
+				pref_age_max INTEGER CHECK(pref_age_max <= 50),
+				profile_set INTEGER DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			// Reports table
+			`CREATE TABLE IF NOT EXISTS reports (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				reporter_id INTEGER,
+				reported_id INTEGER,
+				reason TEXT,
+				context TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (reporter_id) REFERENCES users(user_id),
+				FOREIGN KEY (reported_id) REFERENCES users(user_id)
+			);`,
+
+			// Reactions table
+			`CREATE TABLE IF NOT EXISTS confession_reactions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				confession_id INTEGER,
+				user_id INTEGER,
+				emoji TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE(confession_id, user_id, emoji),
+				FOREIGN KEY (confession_id) REFERENCES confessions(id),
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			// Comments table
+			`CREATE TABLE IF NOT EXISTS confession_comments (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				confession_id INTEGER,
+				user_id INTEGER,
+				username TEXT,
+				text TEXT,
+				anonymous INTEGER DEFAULT 1,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (confession_id) REFERENCES confessions(id),
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			// Admin contacts table
+			`CREATE TABLE IF NOT EXISTS admin_contacts (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER,
+				message TEXT,
+				status TEXT DEFAULT 'pending',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "persist blind chat pairs and queue",
+		Statements: []string{
+			// One row per side of an active blind chat
+			`CREATE TABLE IF NOT EXISTS blind_pairs (
+				user_id INTEGER PRIMARY KEY,
+				partner_id INTEGER NOT NULL,
+				partner_username TEXT,
+				partner_name TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			// Users searching for a blind connection
+			`CREATE TABLE IF NOT EXISTS blind_queue (
+				user_id INTEGER PRIMARY KEY,
+				joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			// Report tallies are counted per reported user
+			`CREATE INDEX IF NOT EXISTS idx_reports_reported_id ON reports(reported_id);`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "voice processing jobs",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS voice_jobs (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				kind TEXT NOT NULL CHECK(kind IN ('confession', 'blind')),
+				user_id INTEGER NOT NULL,
+				chat_id INTEGER NOT NULL,
+				partner_id INTEGER DEFAULT 0,
+				confession_id INTEGER DEFAULT 0,
+				file_id TEXT NOT NULL,
+				gender TEXT,
+				duration INTEGER DEFAULT 0,
+				status TEXT DEFAULT 'queued' CHECK(status IN ('queued', 'processing', 'done', 'failed')),
+				attempts INTEGER DEFAULT 0,
+				next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				progress_message_id INTEGER DEFAULT 0,
+				result_file_id TEXT,
+				last_error TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			`CREATE INDEX IF NOT EXISTS idx_voice_jobs_pending ON voice_jobs(status, next_attempt_at);`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "confession moderation status",
+		Statements: []string{
+			// pending, approved, rejected or deleted; approved alone can't tell
+			// a rejected confession from one still waiting for review
+			`ALTER TABLE confessions ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';`,
+
+			`UPDATE confessions SET status = 'approved' WHERE approved = 1;`,
+
+			// Older unapproved confessions were almost certainly rejected
+			`UPDATE confessions SET status = 'rejected'
+			 WHERE approved = 0 AND date < datetime('now', '-7 days');`,
+
+			`CREATE INDEX IF NOT EXISTS idx_confessions_status ON confessions(status);`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "ban appeals",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS appeals (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL,
+				message TEXT NOT NULL,
+				status TEXT DEFAULT 'pending' CHECK(status IN ('pending', 'accepted', 'denied')),
+				admin_message_id INTEGER DEFAULT 0,
+				resolved_by INTEGER,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				resolved_at TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			`CREATE INDEX IF NOT EXISTS idx_appeals_user_status ON appeals(user_id, status);`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "strike ledger and temporary bans",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS strikes (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL,
+				kind TEXT NOT NULL CHECK(kind IN ('warning', 'temp_ban', 'perm_ban')),
+				reason TEXT,
+				source TEXT NOT NULL CHECK(source IN ('admin', 'reports')),
+				issued_by INTEGER DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				expires_at TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(user_id)
+			);`,
+
+			`CREATE INDEX IF NOT EXISTS idx_strikes_user ON strikes(user_id);`,
+
+			// NULL with banned = 1 means a permanent ban
+			`ALTER TABLE users ADD COLUMN ban_until TIMESTAMP;`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "admin contact threads",
+		Statements: []string{
+			// admin_contacts.status is now pending, answered or closed
+			`ALTER TABLE admin_contacts ADD COLUMN admin_message_id INTEGER DEFAULT 0;`,
+			`ALTER TABLE admin_contacts ADD COLUMN updated_at TIMESTAMP;`,
+
+			// Every message in a contact thread, from either side
+			`CREATE TABLE IF NOT EXISTS admin_contact_messages (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				contact_id INTEGER NOT NULL,
+				sender TEXT NOT NULL CHECK(sender IN ('user', 'admin')),
+				admin_id INTEGER DEFAULT 0,
+				text TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (contact_id) REFERENCES admin_contacts(id)
+			);`,
+
+			// Existing contacts start their thread with the original message
+			`INSERT INTO admin_contact_messages (contact_id, sender, text, created_at)
+			 SELECT id, 'user', COALESCE(message, ''), created_at FROM admin_contacts;`,
+
+			`CREATE INDEX IF NOT EXISTS idx_admin_contact_messages_contact ON admin_contact_messages(contact_id);`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "sliding window rate limits",
+		Statements: []string{
+			// Replaces users.admin_contact_allowed
+			`CREATE TABLE IF NOT EXISTS rate_limit_events (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL,
+				action TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);`,
+
+			`CREATE INDEX IF NOT EXISTS idx_rate_limit_events_lookup ON rate_limit_events(user_id, action, created_at);`,
+
+			// Carry recent history over so nobody gets a fresh allowance on upgrade
+			`INSERT INTO rate_limit_events (user_id, action, created_at)
+			 SELECT user_id, 'admin_contact', created_at FROM admin_contacts
+			 WHERE created_at > datetime('now', '-7 days');`,
+			`INSERT INTO rate_limit_events (user_id, action, created_at)
+			 SELECT user_id, 'confession', date FROM confessions
+			 WHERE date > datetime('now', '-1 day');`,
+			`INSERT INTO rate_limit_events (user_id, action, created_at)
+			 SELECT user_id, 'comment', created_at FROM confession_comments
+			 WHERE created_at > datetime('now', '-1 hour');`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "per-user quota overrides",
+		Statements: []string{
+			// max_count < 0 means unlimited
+			`CREATE TABLE IF NOT EXISTS rate_limit_overrides (
+				user_id INTEGER NOT NULL,
+				action TEXT NOT NULL,
+				max_count INTEGER NOT NULL,
+				window_seconds INTEGER NOT NULL,
+				set_by INTEGER DEFAULT 0,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (user_id, action)
+			);`,
+
+			`INSERT INTO rate_limit_events (user_id, action, created_at)
+			 SELECT user_id, 'confession_hourly', date FROM confessions
+			 WHERE date > datetime('now', '-1 hour');`,
+			`INSERT INTO rate_limit_events (user_id, action, created_at)
+			 SELECT user_id, 'voice_confession', date FROM confessions
+			 WHERE type = 'voice' AND date > datetime('now', '-1 day');`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "comment moderation",
+		Statements: []string{
+			// pending (awaiting approval), visible, hidden or deleted
+			`ALTER TABLE confession_comments ADD COLUMN status TEXT NOT NULL DEFAULT 'visible';`,
+			`ALTER TABLE confession_comments ADD COLUMN admin_message_id INTEGER;`,
+			`CREATE INDEX IF NOT EXISTS idx_comments_confession_status ON confession_comments(confession_id, status);`,
+
+			`CREATE TABLE IF NOT EXISTS comment_reports (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				comment_id INTEGER NOT NULL,
+				reporter_id INTEGER NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (comment_id, reporter_id),
+				FOREIGN KEY (comment_id) REFERENCES confession_comments(id)
+			);`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "comment reactions",
+		Statements: []string{
+			`CREATE TABLE IF NOT EXISTS comment_reactions (
+				comment_id INTEGER NOT NULL,
+				user_id INTEGER NOT NULL,
+				emoji TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (comment_id, user_id, emoji),
+				FOREIGN KEY (comment_id) REFERENCES confession_comments(id)
+			);`,
+		},
+	},
+	{
+		Version: 12,
+		Name:    "threaded comment replies",
+		Statements: []string{
+			// NULL for top-level comments; replies are one level deep
+			`ALTER TABLE confession_comments ADD COLUMN parent_id INTEGER REFERENCES confession_comments(id);`,
+			`CREATE INDEX IF NOT EXISTS idx_comments_parent ON confession_comments(parent_id);`,
+			`ALTER TABLE users ADD COLUMN notify_replies INTEGER DEFAULT 1;`,
+		},
+	},
+	{
+		Version: 13,
+		Name:    "author activity digests",
+		Statements: []string{
+			// Opt-in, unlike reply notifications
+			`ALTER TABLE users ADD COLUMN notify_confessions INTEGER DEFAULT 0;`,
+
+			// kind is comment or milestone; sent_at is set once a digest includes it
+			`CREATE TABLE IF NOT EXISTS author_notifications (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id INTEGER NOT NULL,
+				confession_id INTEGER NOT NULL,
+				kind TEXT NOT NULL,
+				detail TEXT,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				sent_at TIMESTAMP
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_author_notifications_unsent ON author_notifications(sent_at, user_id);`,
+
+			// Each milestone is announced once even if reactions are toggled
+			`CREATE TABLE IF NOT EXISTS reaction_milestones (
+				confession_id INTEGER NOT NULL,
+				emoji TEXT NOT NULL,
+				milestone INTEGER NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (confession_id, emoji, milestone)
+			);`,
+		},
+	},
+	{
+		Version: 14,
+		Name:    "photo confessions",
+		Statements: []string{
+			// photo_id is the metadata-stripped copy; text holds the caption
+			`ALTER TABLE confessions ADD COLUMN photo_id TEXT;`,
+			`ALTER TABLE confessions ADD COLUMN blurred INTEGER DEFAULT 0;`,
+		},
+	},
+	{
+		Version: 15,
+		Name:    "confession drafts",
+		Statements: []string{
+			// One unsent text confession per user
+			`CREATE TABLE IF NOT EXISTS confession_drafts (
+				user_id INTEGER PRIMARY KEY,
+				text TEXT NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);`,
+		},
+	},
+	{
+		Version: 16,
+		Name:    "publishing queue",
+		Statements: []string{
+			// Approved confessions wait as status 'queued' until their slot;
+			// the status list above now includes queued and publishing
+			`ALTER TABLE confessions ADD COLUMN queued_at TIMESTAMP;`,
+			`CREATE INDEX IF NOT EXISTS idx_confessions_queue ON confessions(status, queued_at);`,
+		},
+	},
+	{
+		Version: 17,
+		Name:    "withdrawn confessions",
+		Statements: []string{
+			// The review message, so it can be updated when the author withdraws
+			`ALTER TABLE confessions ADD COLUMN admin_message_id INTEGER;`,
+			`ALTER TABLE confessions ADD COLUMN withdrawn_at TIMESTAMP;`,
+
+			// Reactions of withdrawn confessions; their comments keep their
+			// rows with status 'archived'
+			`CREATE TABLE IF NOT EXISTS archived_reactions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				confession_id INTEGER NOT NULL,
+				user_id INTEGER,
+				emoji TEXT,
+				created_at TIMESTAMP,
+				archived_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			);`,
+		},
+	},
+	{
+		Version: 18,
+		Name:    "rejection reasons",
+		Statements: []string{
+			// A preset reason or an admin's note, shown to the author
+			`ALTER TABLE confessions ADD COLUMN reject_reason TEXT;`,
+		},
+	},
+	{
+		Version: 19,
+		Name:    "draft revisions",
+		Statements: []string{
+			// Preview buttons carry the revision they were shown for
+			`ALTER TABLE confession_drafts ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;`,
+		},
+	},
+	{
+		Version: 20,
+		Name:    "publish attempts",
+		Statements: []string{
+			// Failed posts are retried a few times, then marked failed
+			`ALTER TABLE confessions ADD COLUMN publish_attempts INTEGER NOT NULL DEFAULT 0;`,
+		},
+	},
+}
+
+// runMigrations applies every migration newer than the recorded schema version.
+// Each migration runs in its own transaction together with its schema_migrations
+// row, so a failed step leaves the database at the previous version.
+func runMigrations(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	var current int
+	err = db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	lastVersion := 0
+	for _, m := range migrations {
+		if m.Version <= lastVersion {
+			return fmt.Errorf("migration %d (%s) is out of order", m.Version, m.Name)
+		}
+		lastVersion = m.Version
+
+		if m.Version <= current {
+			continue
+		}
+
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %v", m.Version, m.Name, err)
+		}
+		log.Printf("💾 Applied migration %d: %s", m.Version, m.Name)
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.Statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
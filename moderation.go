@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// ----------------- CONTENT MODERATION -----------------
+
+// Moderation checks, also the keys of ModerationConfig.Actions
+const (
+	CheckBannedWords = "banned_words"
+	CheckPhoneNumber = "phone_number"
+	CheckEmail       = "email"
+	CheckStudentID   = "student_id"
+	CheckUsername    = "username"
+	CheckSpam        = "spam"
+)
+
+// What happens when a check matches
+const (
+	ModerationOff    = "off"
+	ModerationFlag   = "flag"
+	ModerationReject = "reject"
+)
+
+// ModerationConfig drives the automatic screening that runs before text
+// confessions reach the admin group and before comments are saved.
+type ModerationConfig struct {
+	BannedWords      []string          `json:"banned_words"`
+	BannedPatterns   []string          `json:"banned_patterns"`
+	StudentIDPattern string            `json:"student_id_pattern"`
+	Actions          map[string]string `json:"actions"`
+
+	bannedWords    *regexp.Regexp
+	bannedPatterns []*regexp.Regexp
+	studentID      *regexp.Regexp
+}
+
+func defaultModerationConfig() ModerationConfig {
+	return ModerationConfig{
+		StudentIDPattern: `(?i)\b[a-z]{2,4}/\d{3,6}/\d{2}\b`,
+		Actions: map[string]string{
+			CheckBannedWords: ModerationReject,
+			CheckPhoneNumber: ModerationReject,
+			CheckEmail:       ModerationReject,
+			CheckStudentID:   ModerationReject,
+			CheckUsername:    ModerationFlag,
+			CheckSpam:        ModerationReject,
+		},
+	}
+}
+
+// compile builds the configured word list and patterns; it's called from
+// Config.validate so bad patterns stop the bot at startup.
+func (m *ModerationConfig) compile() error {
+	m.bannedWords = nil
+	var words []string
+	for _, word := range m.BannedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+	}
+	if len(words) > 0 {
+		m.bannedWords = regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)
+	}
+
+	m.bannedPatterns = nil
+	for _, pattern := range m.BannedPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("banned pattern %q: %v", pattern, err)
+		}
+		m.bannedPatterns = append(m.bannedPatterns, re)
+	}
+
+	m.studentID = nil
+	if m.StudentIDPattern != "" {
+		re, err := regexp.Compile(m.StudentIDPattern)
+		if err != nil {
+			return fmt.Errorf("student ID pattern: %v", err)
+		}
+		m.studentID = re
+	}
+
+	for check, action := range m.Actions {
+		if action != ModerationOff && action != ModerationFlag && action != ModerationReject {
+			return fmt.Errorf("moderation action for %s must be off, flag or reject", check)
+		}
+	}
+	return nil
+}
+
+// action returns what to do when check matches; checks missing from the
+// config only flag.
+func (m *ModerationConfig) action(check string) string {
+	if action, ok := m.Actions[check]; ok {
+		return action
+	}
+	return ModerationFlag
+}
+
+// ModerationHit is one check that matched, with the offending snippet
+type ModerationHit struct {
+	Check  string
+	Match  string
+	Action string
+}
+
+// ModerationResult is the outcome of running every check over a text
+type ModerationResult struct {
+	Hits []ModerationHit
+}
+
+// Rejected returns the first hit that blocks the submission, if any
+func (r ModerationResult) Rejected() (ModerationHit, bool) {
+	for _, hit := range r.Hits {
+		if hit.Action == ModerationReject {
+			return hit, true
+		}
+	}
+	return ModerationHit{}, false
+}
+
+func (r ModerationResult) Flagged() bool {
+	return len(r.Hits) > 0
+}
+
+// moderationCheck inspects text submitted by userID. kind is "confession" or
+// "comment". It returns the matched snippet, or "" if the text is fine.
+type moderationCheck struct {
+	Name string
+	Run  func(userID int64, kind string, text string) string
+}
+
+var (
+	phoneNumberPattern = regexp.MustCompile(`\+?\(?\d(?:[\s().-]{0,2}\d){8,14}`)
+	emailPattern       = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+	usernamePattern    = regexp.MustCompile(`(?i)(?:^|[^a-z0-9_])(@[a-z][a-z0-9_]{4,31})\b|\b(?:t\.me|telegram\.me)/[a-z0-9_]{5,32}`)
+)
+
+// moderationChecks run in order; add new detectors here
+var moderationChecks = []moderationCheck{
+	{CheckBannedWords, checkBannedWords},
+	{CheckPhoneNumber, checkPhoneNumber},
+	{CheckEmail, checkEmail},
+	{CheckStudentID, checkStudentID},
+	{CheckUsername, checkUsername},
+	{CheckSpam, checkSpam},
+}
+
+// moderateText runs every enabled check over text
+func moderateText(userID int64, kind string, text string) ModerationResult {
+	var result ModerationResult
+	for _, check := range moderationChecks {
+		action := cfg.Moderation.action(check.Name)
+		if action == ModerationOff {
+			continue
+		}
+		if match := check.Run(userID, kind, text); match != "" {
+			result.Hits = append(result.Hits, ModerationHit{Check: check.Name, Match: match, Action: action})
+		}
+	}
+	return result
+}
+
+func checkBannedWords(userID int64, kind string, text string) string {
+	m := &cfg.Moderation
+	if m.bannedWords != nil {
+		if match := m.bannedWords.FindString(text); match != "" {
+			return match
+		}
+	}
+	for _, re := range m.bannedPatterns {
+		if match := re.FindString(text); match != "" {
+			return match
+		}
+	}
+	return ""
+}
+
+func checkPhoneNumber(userID int64, kind string, text string) string {
+	for _, match := range phoneNumberPattern.FindAllString(text, -1) {
+		digits := 0
+		for _, r := range match {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		// Years, prices and counts are shorter than any phone number
+		if digits >= 9 {
+			return match
+		}
+	}
+	return ""
+}
+
+func checkEmail(userID int64, kind string, text string) string {
+	return emailPattern.FindString(text)
+}
+
+func checkStudentID(userID int64, kind string, text string) string {
+	if cfg.Moderation.studentID == nil {
+		return ""
+	}
+	return cfg.Moderation.studentID.FindString(text)
+}
+
+func checkUsername(userID int64, kind string, text string) string {
+	match := usernamePattern.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	if match[1] != "" {
+		return match[1]
+	}
+	return strings.TrimSpace(match[0])
+}
+
+// checkSpam catches keyboard mashing, one word repeated over and over, and
+// the same text submitted twice in a day.
+func checkSpam(userID int64, kind string, text string) string {
+	runes := []rune(text)
+	run := 1
+	for i := 1; i < len(runes); i++ {
+		if runes[i] == runes[i-1] && runes[i] != ' ' {
+			run++
+			if run >= 10 {
+				return string(runes[i-run+1 : i+1])
+			}
+		} else {
+			run = 1
+		}
+	}
+
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) >= 6 {
+		counts := make(map[string]int)
+		for _, word := range words {
+			counts[word]++
+			if counts[word]*2 > len(words) {
+				return fmt.Sprintf("%q repeated %d times", word, counts[word])
+			}
+		}
+	}
+
+	var duplicates int
+	query := `
+		SELECT COUNT(*) FROM confessions
+		WHERE user_id = ? AND text = ? AND date > datetime('now', '-1 day')`
+	if kind == "comment" {
+		query = `
+			SELECT COUNT(*) FROM confession_comments
+			WHERE user_id = ? AND text = ? AND created_at > datetime('now', '-1 day')`
+	}
+	if err := db.QueryRow(query, userID, text).Scan(&duplicates); err != nil {
+		log.Println("Error checking duplicate submissions:", err)
+	}
+	if duplicates > 0 {
+		return "duplicate of a recent submission"
+	}
+	return ""
+}
+
+// moderationReasonText explains a rejection to the author without echoing
+// the rule details that would help get around it.
+func moderationReasonText(check string) string {
+	switch check {
+	case CheckBannedWords:
+		return "It contains language that isn't allowed here."
+	case CheckPhoneNumber:
+		return "It looks like it contains a phone number."
+	case CheckEmail:
+		return "It looks like it contains an email address."
+	case CheckStudentID:
+		return "It looks like it contains a student ID."
+	case CheckUsername:
+		return "It mentions a Telegram username or profile link."
+	case CheckSpam:
+		return "It looks like spam or a repeat of something you already sent."
+	}
+	return "It didn't pass our automatic checks."
+}
+
+// sendModerationRejection tells the author why their text was blocked; they
+// stay in the same step so they can edit and resend.
+func sendModerationRejection(chatID int64, hit ModerationHit, what string) {
+	sendMessageWithKeyboard(chatID,
+		fmt.Sprintf("🛡️ *Not Sent*\n\n"+
+			"Your %s was blocked by our automatic filter.\n\n"+
+			"📋 *Reason:* %s\n\n"+
+			"Please edit it and send it again, or cancel.\n"+
+			"🔒 Never share anyone's contact details or identity.",
+			what, moderationReasonText(hit.Check)),
+		createCancelKeyboard())
+}
+
+// moderationFlagsText renders the hits for an admin message
+func moderationFlagsText(result ModerationResult) string {
+	if !result.Flagged() {
+		return ""
+	}
+	var lines []string
+	for _, hit := range result.Hits {
+		lines = append(lines, fmt.Sprintf("• %s: `%s`",
+			moderationCheckText(hit.Check), strings.ReplaceAll(hit.Match, "`", "'")))
+	}
+	return "🛡️ *Auto-moderation flags:*\n" + strings.Join(lines, "\n") + "\n\n"
+}
+
+func moderationCheckText(check string) string {
+	switch check {
+	case CheckBannedWords:
+		return "Banned word"
+	case CheckPhoneNumber:
+		return "Phone number"
+	case CheckEmail:
+		return "Email"
+	case CheckStudentID:
+		return "Student ID"
+	case CheckUsername:
+		return "Username"
+	case CheckSpam:
+		return "Spam"
+	}
+	return check
+}
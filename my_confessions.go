@@ -0,0 +1,303 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- MY CONFESSIONS -----------------
+
+// /myconfessions lists a user's own confessions and lets them withdraw any
+// that is still pending, queued or published. Withdrawing takes a pending
+// confession out of review, deletes a published one from the channel, and
+// archives its comments and reactions (status withdrawn).
+
+const myConfessionsLimit = 10
+
+// saveAdminMessageID remembers the review message for a confession so it
+// can be updated later
+func saveAdminMessageID(confessionID int, messageID int) {
+	if _, err := db.Exec("UPDATE confessions SET admin_message_id = ? WHERE id = ?", messageID, confessionID); err != nil {
+		log.Println("Error saving admin message ID:", err)
+	}
+}
+
+func confessionStatusText(status string) string {
+	switch status {
+	case "pending":
+		return "⏳ Awaiting review"
+	case "queued", "publishing":
+		return "🗓️ Approved, waiting to post"
+	case "approved":
+		return "✅ Published"
+	case "rejected":
+		return "❌ Not approved"
+	case "deleted":
+		return "🗑️ Removed by admins"
+	case "withdrawn":
+		return "↩️ Withdrawn"
+	case "failed":
+		return "⚠️ Approved, couldn't be posted yet"
+	}
+	return status
+}
+
+func canWithdraw(status string) bool {
+	return status == "pending" || status == "queued" || status == "failed" || status == "approved"
+}
+
+// renderMyConfessions builds the /myconfessions list with a Withdraw button
+// for each confession that can still be taken back
+func renderMyConfessions(userID int64, note string) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	noButtons := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+
+	rows, err := db.Query(`
+		SELECT id, type, COALESCE(text, ''), status, COALESCE(reject_reason, ''), date
+		FROM confessions
+		WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT ?`, userID, myConfessionsLimit)
+	if err != nil {
+		return "", noButtons, err
+	}
+	defer rows.Close()
+
+	var lines []string
+	var buttons [][]tgbotapi.InlineKeyboardButton
+	for rows.Next() {
+		var c Confession
+		var status, rejectReason string
+		if err := rows.Scan(&c.ID, &c.Type, &c.Text, &status, &rejectReason, &c.Date); err != nil {
+			log.Println("Error scanning confession:", err)
+			continue
+		}
+
+		preview := truncateText(c.Text, 60)
+		switch c.Type {
+		case "voice":
+			preview = "🎤 Voice confession"
+		case "photo":
+			preview = "📸 Photo · " + truncateText(c.Text, 50)
+		}
+		line := fmt.Sprintf("*#%d* · %s · %s\n%s",
+			c.ID, c.Date.Format("Jan 2"), confessionStatusText(status), escapeMarkdown(preview))
+		if status == "rejected" && rejectReason != "" {
+			line += "\n📋 " + escapeMarkdown(rejectReason)
+		}
+		lines = append(lines, line)
+
+		if canWithdraw(status) {
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("↩️ Withdraw #%d", c.ID), fmt.Sprintf("mine:withdraw:%d", c.ID))))
+		}
+	}
+
+	if len(lines) == 0 {
+		return note + "📭 *No confessions yet*\n\nUse 📝 Text Confession to write your first one.", noButtons, nil
+	}
+
+	text := fmt.Sprintf("%s📜 *MY CONFESSIONS*\n──────────────\n\n%s\n\n"+
+		"──────────────\n"+
+		"🔒 Only you can see this list.", note, strings.Join(lines, "\n\n"))
+	if len(buttons) == 0 {
+		return text, noButtons, nil
+	}
+	return text, tgbotapi.NewInlineKeyboardMarkup(buttons...), nil
+}
+
+func sendMyConfessions(userID int64, chatID int64) {
+	text, keyboard, err := renderMyConfessions(userID, "")
+	if err != nil {
+		log.Println("Error loading user confessions:", err)
+		sendMessage(chatID, "❌ *Error loading your confessions*")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	if len(keyboard.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	bot.Send(msg)
+}
+
+func editMyConfessions(cb *tgbotapi.CallbackQuery, note string) {
+	text, keyboard, err := renderMyConfessions(cb.From.ID, note)
+	if err != nil {
+		log.Println("Error loading user confessions:", err)
+		return
+	}
+	editMsg := tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID, text, keyboard)
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+}
+
+// handleMineCallback handles mine:withdraw:<id> (asks to confirm),
+// mine:confirm:<id> and mine:list:0 from the /myconfessions message
+func handleMineCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 3 || cb.Message == nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	switch parts[1] {
+	case "list":
+		editMyConfessions(cb, "")
+		bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+		return
+	case "withdraw", "confirm":
+	default:
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown action"))
+		return
+	}
+
+	confessionID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	var status string
+	err = db.QueryRow("SELECT status FROM confessions WHERE id = ? AND user_id = ?", confessionID, cb.From.ID).Scan(&status)
+	if err != nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Confession not found"))
+		return
+	}
+
+	if parts[1] == "withdraw" {
+		warning := "It will be taken out of the review queue."
+		if status == "approved" {
+			warning = "It will be deleted from the channel, along with its comments and reactions."
+		}
+		editMsg := tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID,
+			fmt.Sprintf("↩️ *Withdraw confession #%d?*\n\n%s\n\nThis can't be undone.", confessionID, warning),
+			tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("↩️ Yes, withdraw", fmt.Sprintf("mine:confirm:%d", confessionID)),
+				tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", "mine:list:0"),
+			)))
+		editMsg.ParseMode = "Markdown"
+		bot.Send(editMsg)
+		bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+		return
+	}
+
+	if err := withdrawConfession(cb.From.ID, confessionID); err != nil {
+		log.Printf("Error withdrawing confession #%d: %v", confessionID, err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Couldn't withdraw it"))
+		editMyConfessions(cb, fmt.Sprintf("❌ *Couldn't withdraw #%d.* Please try again or contact the admins.\n\n", confessionID))
+		return
+	}
+
+	editMyConfessions(cb, fmt.Sprintf("↩️ *Confession #%d withdrawn.*\n\n", confessionID))
+	bot.Send(tgbotapi.NewCallback(cb.ID, "↩️ Withdrawn"))
+}
+
+// withdrawConfession takes back one of the user's confessions
+func withdrawConfession(userID int64, confessionID int) error {
+	var status, confessionType string
+	var channelMessageID, adminMessageID sql.NullInt64
+	err := db.QueryRow(`
+		SELECT status, type, channel_message_id, admin_message_id
+		FROM confessions WHERE id = ? AND user_id = ?`, confessionID, userID).Scan(
+		&status, &confessionType, &channelMessageID, &adminMessageID)
+	if err != nil {
+		return err
+	}
+	if !canWithdraw(status) {
+		return fmt.Errorf("confession is %s", status)
+	}
+
+	// Take it off the channel first; if that fails nothing else changes
+	if status == "approved" && channelMessageID.Valid && channelMessageID.Int64 != 0 {
+		if _, err := bot.Request(tgbotapi.NewDeleteMessage(channelID, int(channelMessageID.Int64))); err != nil {
+			return fmt.Errorf("failed to delete channel post: %v", err)
+		}
+	}
+
+	// The status check keeps an admin decision or a scheduled post that
+	// landed in the meantime from being overwritten
+	result, err := db.Exec(`
+		UPDATE confessions
+		SET status = 'withdrawn', approved = 0, withdrawn_at = datetime('now')
+		WHERE id = ? AND status = ?`, confessionID, status)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return fmt.Errorf("confession changed while withdrawing")
+	}
+
+	if status == "approved" {
+		if err := archiveConfessionActivity(confessionID); err != nil {
+			log.Println("Error archiving confession activity:", err)
+		}
+	}
+
+	markAdminMessageWithdrawn(confessionID, confessionType, status, adminMessageID)
+	return nil
+}
+
+// archiveConfessionActivity hides a withdrawn confession's comments and
+// moves its reactions out of the live table
+func archiveConfessionActivity(confessionID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`UPDATE confession_comments SET status = 'archived'
+		 WHERE confession_id = ? AND status != 'deleted'`,
+		`INSERT INTO archived_reactions (confession_id, user_id, emoji, created_at)
+		 SELECT confession_id, user_id, emoji, created_at
+		 FROM confession_reactions WHERE confession_id = ?`,
+		`DELETE FROM confession_reactions WHERE confession_id = ?`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, confessionID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// markAdminMessageWithdrawn updates the review message so admins stop
+// acting on it. Voice confessions are reviewed on the voice message itself,
+// so their caption is edited instead of the text.
+func markAdminMessageWithdrawn(confessionID int, confessionType string, status string, adminMessageID sql.NullInt64) {
+	text := fmt.Sprintf("↩️ *WITHDRAWN* #%d\n──────────────\n\n"+
+		"The author took this confession back before it was posted.", confessionID)
+	if status == "approved" {
+		text = fmt.Sprintf("↩️ *WITHDRAWN* #%d\n──────────────\n\n"+
+			"The author withdrew this confession. It was deleted from the channel "+
+			"and its comments and reactions were archived.", confessionID)
+	}
+
+	if !adminMessageID.Valid || adminMessageID.Int64 == 0 {
+		// Nothing to edit, so tell the admins directly about published ones
+		if status == "approved" {
+			sendMessage(adminGroupID, text)
+		}
+		return
+	}
+
+	messageID := int(adminMessageID.Int64)
+	noButtons := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if confessionType == "voice" {
+		editCaption := tgbotapi.NewEditMessageCaption(adminGroupID, messageID, text)
+		editCaption.ParseMode = "Markdown"
+		editCaption.ReplyMarkup = &noButtons
+		bot.Send(editCaption)
+		return
+	}
+
+	editMsg := tgbotapi.NewEditMessageTextAndMarkup(adminGroupID, messageID, text, noButtons)
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- AUTHOR ACTIVITY DIGESTS -----------------
+
+// Authors who opt in hear about new comments and reaction milestones on
+// their confessions. Events are queued in author_notifications and sent as
+// one digest per author every cfg.NotificationDigestInterval. Only the
+// author is ever messaged, so commenters learn nothing about them.
+
+func authorNotificationsEnabled(userID int64) bool {
+	var enabled sql.NullInt64
+	err := db.QueryRow("SELECT notify_confessions FROM users WHERE user_id = ?", userID).Scan(&enabled)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("Error loading notification setting:", err)
+	}
+	return enabled.Valid && enabled.Int64 == 1
+}
+
+func setAuthorNotifications(userID int64, enabled bool) error {
+	value := 0
+	if enabled {
+		value = 1
+	}
+	_, err := db.Exec("UPDATE users SET notify_confessions = ? WHERE user_id = ?", value, userID)
+	return err
+}
+
+// queueAuthorNotification records an event for the confession's author
+// unless they caused it themselves or haven't opted in.
+func queueAuthorNotification(confessionID int, kind string, detail string, actorID int64) {
+	var authorID int64
+	err := db.QueryRow("SELECT user_id FROM confessions WHERE id = ?", confessionID).Scan(&authorID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("Error loading confession author:", err)
+		}
+		return
+	}
+	if authorID == actorID || !authorNotificationsEnabled(authorID) {
+		return
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO author_notifications (user_id, confession_id, kind, detail)
+		VALUES (?, ?, ?, ?)`, authorID, confessionID, kind, detail)
+	if err != nil {
+		log.Println("Error queueing author notification:", err)
+	}
+}
+
+// onCommentVisible runs once a comment becomes visible, either right away
+// or after admin approval.
+func onCommentVisible(commentID int64) {
+	c, err := getComment(commentID)
+	if err != nil || c.Status != "visible" {
+		return
+	}
+	notifyCommentReply(commentID)
+	queueAuthorNotification(c.ConfessionID, "comment", "", c.UserID)
+}
+
+// checkReactionMilestones queues a notification the first time a reaction
+// count on a confession reaches one of cfg.ReactionMilestones.
+func checkReactionMilestones(confessionID int, emoji string, actorID int64) {
+	var count int
+	db.QueryRow(`
+		SELECT COUNT(*) FROM confession_reactions
+		WHERE confession_id = ? AND emoji = ?`, confessionID, emoji).Scan(&count)
+
+	for _, milestone := range cfg.ReactionMilestones {
+		if count < milestone {
+			continue
+		}
+		result, err := db.Exec(`
+			INSERT OR IGNORE INTO reaction_milestones (confession_id, emoji, milestone)
+			VALUES (?, ?, ?)`, confessionID, emoji, milestone)
+		if err != nil {
+			log.Println("Error recording reaction milestone:", err)
+			continue
+		}
+		if inserted, _ := result.RowsAffected(); inserted > 0 {
+			queueAuthorNotification(confessionID, "milestone", fmt.Sprintf("%d %s", milestone, emoji), actorID)
+		}
+	}
+}
+
+func notificationDigestRoutine() {
+	ticker := time.NewTicker(cfg.NotificationDigestInterval.Duration)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sendNotificationDigests()
+	}
+}
+
+type confessionActivity struct {
+	Comments   int
+	Milestones []string
+}
+
+func sendNotificationDigests() {
+	rows, err := db.Query(`
+		SELECT id, user_id, confession_id, kind, COALESCE(detail, '')
+		FROM author_notifications
+		WHERE sent_at IS NULL
+		ORDER BY id`)
+	if err != nil {
+		log.Println("Error loading author notifications:", err)
+		return
+	}
+
+	activity := make(map[int64]map[int]*confessionActivity)
+	lastID := make(map[int64]int64)
+	for rows.Next() {
+		var id, userID int64
+		var confessionID int
+		var kind, detail string
+		if err := rows.Scan(&id, &userID, &confessionID, &kind, &detail); err != nil {
+			log.Println("Error scanning author notification:", err)
+			continue
+		}
+		if activity[userID] == nil {
+			activity[userID] = make(map[int]*confessionActivity)
+		}
+		a := activity[userID][confessionID]
+		if a == nil {
+			a = &confessionActivity{}
+			activity[userID][confessionID] = a
+		}
+		switch kind {
+		case "comment":
+			a.Comments++
+		case "milestone":
+			a.Milestones = append(a.Milestones, detail)
+		}
+		lastID[userID] = id
+	}
+	rows.Close()
+
+	for userID, confessions := range activity {
+		// Authors who opted out since the events were queued get nothing
+		if authorNotificationsEnabled(userID) {
+			sendNotificationDigest(userID, confessions)
+		}
+		_, err := db.Exec(`
+			UPDATE author_notifications SET sent_at = datetime('now')
+			WHERE user_id = ? AND sent_at IS NULL AND id <= ?`, userID, lastID[userID])
+		if err != nil {
+			log.Println("Error marking author notifications sent:", err)
+		}
+	}
+
+	// Keep a week of history for debugging, nothing more
+	db.Exec("DELETE FROM author_notifications WHERE sent_at < datetime('now', '-7 days')")
+}
+
+func sendNotificationDigest(userID int64, confessions map[int]*confessionActivity) {
+	var ids []int
+	for confessionID := range confessions {
+		ids = append(ids, confessionID)
+	}
+	sort.Ints(ids)
+
+	var lines []string
+	for _, confessionID := range ids {
+		a := confessions[confessionID]
+		var parts []string
+		if a.Comments == 1 {
+			parts = append(parts, "💬 1 new comment")
+		} else if a.Comments > 1 {
+			parts = append(parts, fmt.Sprintf("💬 %d new comments", a.Comments))
+		}
+		for _, milestone := range a.Milestones {
+			parts = append(parts, "🎉 reached "+milestone)
+		}
+		lines = append(lines, fmt.Sprintf("📜 [Confession #%d](https://t.me/%s?start=view%d)\n%s",
+			confessionID, botUsername, confessionID, strings.Join(parts, "\n")))
+	}
+
+	sendMessage(userID, fmt.Sprintf("🔔 *ACTIVITY ON YOUR CONFESSIONS*\n──────────────\n\n%s\n\n"+
+		"──────────────\n"+
+		"🔒 Nobody knows these are yours.\n"+
+		"Use /notifications off to stop these updates.",
+		strings.Join(lines, "\n\n")))
+}
+
+// offerAuthorNotifications asks a newly published author whether they want
+// activity digests, unless they already get them.
+func offerAuthorNotifications(userID int64) {
+	if authorNotificationsEnabled(userID) {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID,
+		"🔔 *Want to know how it lands?*\n\n"+
+			"Get an occasional digest when people comment on or react to your confessions. "+
+			"It stays completely anonymous.")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔔 Notify me", "notify:on"),
+			tgbotapi.NewInlineKeyboardButtonData("🔕 No thanks", "notify:off"),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleNotifyCallback handles notify:on|off from the publish prompt
+func handleNotifyCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 || cb.Message == nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	enabled := parts[1] == "on"
+	if err := setAuthorNotifications(cb.From.ID, enabled); err != nil {
+		log.Println("Error updating notification setting:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	text := "🔕 *No activity digests*\n\nUse /notifications on if you change your mind."
+	if enabled {
+		text = "🔔 *Activity digests on*\n\nWe'll send you a summary of new comments and reactions. Use /notifications off to stop."
+	}
+	editMsg := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, text)
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+
+	bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Saved"))
+}
+
+// handleNotificationsCommand handles /notifications [on|off]
+func handleNotificationsCommand(userID int64, chatID int64, args string) {
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		if err := setAuthorNotifications(userID, true); err != nil {
+			log.Println("Error updating notification setting:", err)
+			sendMessage(chatID, "❌ *Error updating your settings*")
+			return
+		}
+		sendMessage(chatID, fmt.Sprintf("🔔 *Activity digests on*\n\n"+
+			"You'll get a summary of new comments and reaction milestones at most every %s.",
+			formatWait(cfg.NotificationDigestInterval.Duration)))
+
+	case "off":
+		if err := setAuthorNotifications(userID, false); err != nil {
+			log.Println("Error updating notification setting:", err)
+			sendMessage(chatID, "❌ *Error updating your settings*")
+			return
+		}
+		sendMessage(chatID, "🔕 *Activity digests off*\n\nUse /notifications on to turn them back on.")
+
+	default:
+		state := "🔕 Off"
+		if authorNotificationsEnabled(userID) {
+			state = "🔔 On"
+		}
+		sendMessage(chatID, fmt.Sprintf("🔔 *Activity digests:* %s\n\n"+
+			"Digests tell you about new comments and reaction milestones on your confessions.\n\n"+
+			"Use `/notifications on` or `/notifications off` to change.", state))
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- PHOTO CONFESSIONS -----------------
+
+// Photos are re-encoded with FFmpeg before anyone else sees them, which drops
+// EXIF (camera, GPS location, timestamps) and any other embedded metadata.
+// The author gets a preview of the cleaned photo and can submit it as is or
+// blurred. The preview's file IDs live in the session until they decide.
+
+// Telegram captions are limited to 1024 characters including the frosted
+// frame, so photo captions get a tighter limit than text confessions.
+const photoCaptionMaxLength = 800
+
+// Photos are processed in the background so a slow download or FFmpeg run
+// doesn't hold up the dispatcher worker. photoJobSlots caps how many run at
+// once, and photoProcessTimeout bounds each one, download included.
+const (
+	photoJobWorkers     = 2
+	photoProcessTimeout = 60 * time.Second
+)
+
+var photoJobSlots = make(chan struct{}, photoJobWorkers)
+
+func runPhotoJob(userID int64, job func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Photo job for user %d recovered from panic: %v\n%s", userID, r, debug.Stack())
+			}
+		}()
+		photoJobSlots <- struct{}{}
+		defer func() { <-photoJobSlots }()
+		job()
+	}()
+}
+
+func photoCaptionLimit() int {
+	if cfg.ConfessionMaxLength < photoCaptionMaxLength {
+		return cfg.ConfessionMaxLength
+	}
+	return photoCaptionMaxLength
+}
+
+func handlePhotoConfessionButton(userID int64, chatID int64) {
+	if !allowConfession(userID, chatID, "photo") {
+		return
+	}
+
+	sessions.SetConfessionType(userID, "photo")
+	sessions.SetKeyboard(chatID, createCancelKeyboard())
+	sendMessageWithKeyboard(chatID,
+		"📸 *Photo Confession*\n──────────────\n\n"+
+			"✨ *Share a picture anonymously!*\n\n"+
+			"🧼 *Privacy:*\n"+
+			"• Location, camera and time data are removed\n"+
+			"• You can blur the photo before submitting\n"+
+			"• You'll see a preview first\n\n"+
+			"📋 *Guidelines:*\n"+
+			fmt.Sprintf("• Optional caption, max %d characters\n", photoCaptionLimit())+
+			"• No faces, names or anything identifying\n"+
+			"• Be respectful\n\n"+
+			"──────────────\n"+
+			"*Send your photo now...*",
+		createCancelKeyboard())
+}
+
+// confessionPhotoFileID picks the largest size of a photo, or an image sent
+// as a file (which is where original EXIF data survives).
+func confessionPhotoFileID(msg *tgbotapi.Message) (string, bool) {
+	if len(msg.Photo) > 0 {
+		return msg.Photo[len(msg.Photo)-1].FileID, true
+	}
+	if msg.Document != nil && strings.HasPrefix(msg.Document.MimeType, "image/") {
+		return msg.Document.FileID, true
+	}
+	return "", false
+}
+
+// handlePhotoConfession cleans the photo and shows the author a preview with
+// the submit and blur buttons.
+func handlePhotoConfession(userID int64, chatID int64, fileID string, caption string) {
+	caption = strings.TrimSpace(caption)
+	if len(caption) > photoCaptionLimit() {
+		sendMessageWithKeyboard(chatID,
+			fmt.Sprintf("📏 *Caption Too Long*\n\nPhoto captions must be under %d characters.", photoCaptionLimit()),
+			createCancelKeyboard())
+		return
+	}
+
+	if caption != "" {
+		if hit, rejected := moderateText(userID, "confession", caption).Rejected(); rejected {
+			sendModerationRejection(chatID, hit, "caption")
+			return
+		}
+	}
+
+	sendMessageWithKeyboard(chatID, "🧼 *Removing photo metadata...*", createMainMenuKeyboard())
+
+	runPhotoJob(userID, func() {
+		cleaned, err := processConfessionPhoto(fileID, false)
+		if err != nil {
+			log.Println("Error processing photo confession:", err)
+			sendMessageWithKeyboard(chatID,
+				"❌ *Error*\n\nWe couldn't process that photo. Please try another one.",
+				createCancelKeyboard())
+			return
+		}
+
+		previewID, err := sendPhotoPreview(chatID, cleaned, false)
+		if err != nil {
+			log.Println("Error sending photo preview:", err)
+			sendMessageWithKeyboard(chatID,
+				"❌ *Error*\n\nWe couldn't process that photo. Please try another one.",
+				createCancelKeyboard())
+			return
+		}
+
+		sessions.ClearConfessionType(userID)
+		sessions.SetData(userID, "photo_clean", previewID)
+		sessions.SetData(userID, "photo_caption", caption)
+		sessions.SetKeyboard(chatID, createMainMenuKeyboard())
+	})
+}
+
+// sendPhotoPreview uploads a processed photo to the author and returns the
+// file ID Telegram assigned to it.
+func sendPhotoPreview(chatID int64, image []byte, blurred bool) (string, error) {
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "confession.jpg", Bytes: image})
+	photo.ParseMode = "Markdown"
+	if blurred {
+		photo.Caption = "🌫️ *Blurred preview*\n\nSubmit this version, or use the buttons on the original."
+		photo.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Submit blurred", "photo:submit:blurred"),
+				tgbotapi.NewInlineKeyboardButtonData("❌ Discard", "photo:discard"),
+			),
+		)
+	} else {
+		photo.Caption = "🧼 *Preview, metadata removed*\n\nThis is exactly what the admins will see."
+		photo.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("✅ Submit", "photo:submit:clean"),
+				tgbotapi.NewInlineKeyboardButtonData("🌫️ Blur", "photo:blur"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("❌ Discard", "photo:discard"),
+			),
+		)
+	}
+
+	sent, err := bot.Send(photo)
+	if err != nil {
+		return "", err
+	}
+	if len(sent.Photo) == 0 {
+		return "", fmt.Errorf("preview has no photo")
+	}
+	return sent.Photo[len(sent.Photo)-1].FileID, nil
+}
+
+// handlePhotoCallback handles photo:blur, photo:submit:<clean|blurred> and
+// photo:discard from the preview messages.
+func handlePhotoCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 || cb.Message == nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	userID := cb.From.ID
+	chatID := cb.Message.Chat.ID
+
+	// Banned users keep their old previews, so the buttons must not work
+	if isBanned(userID) {
+		clearPhotoPreview(userID)
+		removeInlineKeyboard(cb)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "🤫 Your account has been restricted"))
+		return
+	}
+
+	value, ok := sessions.GetData(userID, "photo_clean")
+	cleanID, _ := value.(string)
+	if !ok || cleanID == "" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "⌛ This preview has expired"))
+		removeInlineKeyboard(cb)
+		return
+	}
+
+	switch parts[1] {
+	case "blur":
+		bot.Send(tgbotapi.NewCallback(cb.ID, "🌫️ Blurring..."))
+		runPhotoJob(userID, func() {
+			blurred, err := processConfessionPhoto(cleanID, true)
+			if err != nil {
+				log.Println("Error blurring photo confession:", err)
+				sendMessage(chatID, "❌ *Error*\n\nWe couldn't blur that photo. You can still submit the original.")
+				return
+			}
+			blurredID, err := sendPhotoPreview(chatID, blurred, true)
+			if err != nil {
+				log.Println("Error sending blurred preview:", err)
+				return
+			}
+			sessions.SetData(userID, "photo_blurred", blurredID)
+		})
+
+	case "submit":
+		photoID := cleanID
+		blurred := len(parts) > 2 && parts[2] == "blurred"
+		if blurred {
+			value, _ := sessions.GetData(userID, "photo_blurred")
+			photoID, _ = value.(string)
+			if photoID == "" {
+				bot.Send(tgbotapi.NewCallback(cb.ID, "⌛ This preview has expired"))
+				return
+			}
+		}
+		value, _ := sessions.GetData(userID, "photo_caption")
+		caption, _ := value.(string)
+
+		bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+		if submitPhotoConfession(userID, chatID, photoID, caption, blurred) {
+			clearPhotoPreview(userID)
+			removeInlineKeyboard(cb)
+		}
+
+	case "discard":
+		clearPhotoPreview(userID)
+		removeInlineKeyboard(cb)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "🗑️ Discarded"))
+		sendMessageWithKeyboard(chatID, "🗑️ *Photo discarded*\n\nNothing was sent to the admins.", createMainMenuKeyboard())
+
+	default:
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown action"))
+	}
+}
+
+func clearPhotoPreview(userID int64) {
+	sessions.SetData(userID, "photo_clean", "")
+	sessions.SetData(userID, "photo_blurred", "")
+	sessions.SetData(userID, "photo_caption", "")
+}
+
+func removeInlineKeyboard(cb *tgbotapi.CallbackQuery) {
+	bot.Send(tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}))
+}
+
+// submitPhotoConfession saves the confession and sends it for review. It
+// reports whether the photo was submitted.
+func submitPhotoConfession(userID int64, chatID int64, photoID string, caption string, blurred bool) bool {
+	if !allowConfession(userID, chatID, "photo") {
+		return false
+	}
+
+	moderation := moderateText(userID, "confession", caption)
+	if caption == "" {
+		moderation = ModerationResult{}
+	}
+
+	blurredValue := 0
+	if blurred {
+		blurredValue = 1
+	}
+	result, err := db.Exec(`
+		INSERT INTO confessions (user_id, text, photo_id, blurred, type, date)
+		VALUES (?, ?, ?, ?, 'photo', datetime('now'))`,
+		userID, caption, photoID, blurredValue)
+	if err != nil {
+		log.Println("Error saving photo confession:", err)
+		sendMessage(chatID, "❌ *Error*\n\nFailed to save confession. Please try again.")
+		return false
+	}
+	confessionID, _ := result.LastInsertId()
+
+	recordConfession(userID, "photo")
+	sendPhotoToAdmin(int(confessionID), userID, photoID, caption, blurred, moderation)
+	sendConfessionSubmittedMessage(chatID, "photo")
+	return true
+}
+
+func sendPhotoToAdmin(confessionID int, userID int64, photoID string, caption string, blurred bool, moderation ModerationResult) {
+	blurText := "No"
+	if blurred {
+		blurText = "Yes"
+	}
+	captionText := "—"
+	if caption != "" {
+		captionText = escapeMarkdown(caption)
+	}
+
+	adminText := fmt.Sprintf(
+		"📸 *NEW PHOTO CONFESSION* #%d\n──────────────\n\n"+
+			"💭 *Caption:*\n%s\n\n"+
+			"%s"+
+			"🧼 *Metadata:* Removed\n"+
+			"🌫️ *Blurred:* %s\n"+
+			"──────────────\n"+
+			"👤 *Sender ID:* `%d`\n"+
+			"🕐 *Time:* %s\n"+
+			"──────────────",
+		confessionID, captionText, moderationFlagsText(moderation), blurText, userID, time.Now().Format("Jan 2, 3:04 PM"))
+
+	// The photo goes first and the review card replies to it, so approve and
+	// reject can edit the card like they do for text and voice.
+	sentPhoto, err := bot.Send(tgbotapi.NewPhoto(adminGroupID, tgbotapi.FileID(photoID)))
+	if err != nil {
+		log.Println("Error sending photo confession to admins:", err)
+		return
+	}
+
+	adminMsg := tgbotapi.NewMessage(adminGroupID, adminText)
+	adminMsg.ParseMode = "Markdown"
+	adminMsg.ReplyToMessageID = sentPhoto.MessageID
+	adminMsg.ReplyMarkup = createAdminApprovalKeyboard(confessionID, "photo")
+	sent, err := bot.Send(adminMsg)
+	if err != nil {
+		log.Println("Error sending photo confession to admins:", err)
+		return
+	}
+	saveAdminMessageID(confessionID, sent.MessageID)
+}
+
+// processConfessionPhoto downloads a photo and re-encodes it as a JPEG with
+// all metadata dropped, optionally blurred.
+func processConfessionPhoto(fileID string, blur bool) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), photoProcessTimeout)
+	defer cancel()
+
+	file, err := bot.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %v", err)
+	}
+
+	tempDir, err := os.MkdirTemp(os.TempDir(), "photo_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	inputFile := filepath.Join(tempDir, "input")
+	outputFile := filepath.Join(tempDir, "clean.jpg")
+
+	// Try wget first, then curl, like the voice pipeline
+	fileURL := fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", bot.Token, file.FilePath)
+	if output, err := exec.CommandContext(ctx, "wget", "-q", "-O", inputFile, fileURL).CombinedOutput(); err != nil {
+		log.Printf("Wget failed, trying curl: %v, output: %s", err, string(output))
+		if output, err := exec.CommandContext(ctx, "curl", "-s", "-o", inputFile, fileURL).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to download photo: %v, output: %s", err, string(output))
+		}
+	}
+
+	args := []string{
+		"-y", "-i", inputFile,
+		"-map_metadata", "-1", // EXIF, GPS, comments
+		"-fflags", "+bitexact", "-flags:v", "+bitexact", // No encoder tag either
+		"-frames:v", "1",
+		"-q:v", "3",
+	}
+	if blur {
+		args = append(args, "-vf",
+			"boxblur=luma_radius='min(w,h)/20':luma_power=3:chroma_radius='min(cw,ch)/20':chroma_power=3")
+	}
+	args = append(args, outputFile)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("FFmpeg photo stderr: %s", stderr.String())
+		return nil, fmt.Errorf("ffmpeg photo processing failed: %v", err)
+	}
+
+	return os.ReadFile(outputFile)
+}
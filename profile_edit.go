@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- PROFILE EDITING -----------------
+
+// profileEditFields maps the editor buttons to the profile_* steps used while
+// creating a profile. Gender is permanent and has no entry.
+var profileEditFields = map[string]struct {
+	Step   string
+	Prompt string
+}{
+	"age":          {"profile_age", "✨ *Please enter your age (18-50):*"},
+	"years_campus": {"profile_years_campus", "✨ *Enter number of years on campus (0-10):*"},
+	"year_study":   {"profile_year_study", "✨ *Select your current year:*"},
+	"pref_gender":  {"profile_pref_gender", "✨ *Who would you like to connect with?*"},
+	"pref_age":     {"profile_pref_age", "✨ *Enter minimum preferred age (18-50):*"},
+}
+
+func showProfileEditor(userID int64, chatID int64) {
+	profile, err := getBlindProfile(userID)
+	if err != nil || !profile.ProfileSet {
+		sendMessage(chatID, "📋 *No Profile Found*\n\nCreate your blind connection profile first with /blind!")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID,
+		fmt.Sprintf("✏️ *Edit Your Profile*\n──────────────\n\n"+
+			"🎂 *Age:* %d\n"+
+			"🏫 *Years on Campus:* %d\n"+
+			"📚 *Year of Study:* %s\n"+
+			"👫 *Looking for:* %s\n"+
+			"🎂 *Age Range:* %d - %d\n\n"+
+			"Gender is permanent and cannot be edited.\n"+
+			"*Pick a field to change:*",
+			profile.Age, profile.YearsOnCampus, profile.YearOfStudy,
+			profile.PrefGender, profile.PrefAgeMin, profile.PrefAgeMax))
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = createProfileEditKeyboard()
+	bot.Send(msg)
+}
+
+func createProfileEditKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎂 Age", "editprofile:age"),
+			tgbotapi.NewInlineKeyboardButtonData("🏫 Years on Campus", "editprofile:years_campus"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📚 Year of Study", "editprofile:year_study"),
+			tgbotapi.NewInlineKeyboardButtonData("👫 Looking For", "editprofile:pref_gender"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎂 Preferred Ages", "editprofile:pref_age"),
+		),
+	)
+}
+
+func handleEditProfileCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	field, ok := profileEditFields[parts[1]]
+	if !ok {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown field"))
+		return
+	}
+
+	userID := cb.From.ID
+	chatID := cb.Message.Chat.ID
+
+	profile, err := getBlindProfile(userID)
+	if err != nil || !profile.ProfileSet {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ No profile found"))
+		return
+	}
+
+	// Seed the step data with the current profile so the step handlers
+	// only replace the field being edited
+	data := map[string]interface{}{
+		"editing":         true,
+		"gender":          profile.Gender,
+		"age":             profile.Age,
+		"years_on_campus": profile.YearsOnCampus,
+		"year_of_study":   profile.YearOfStudy,
+		"pref_gender":     profile.PrefGender,
+		"pref_age_min":    profile.PrefAgeMin,
+		"pref_age_max":    profile.PrefAgeMax,
+	}
+	if field.Step == "profile_pref_age" {
+		// The age range step asks for the minimum first when it is missing
+		delete(data, "pref_age_min")
+		delete(data, "pref_age_max")
+	}
+	sessions.StartStep(userID, field.Step, data)
+
+	bot.Send(tgbotapi.NewCallback(cb.ID, "✏️ Editing"))
+
+	keyboard := createCancelKeyboard()
+	switch field.Step {
+	case "profile_year_study":
+		keyboard = createYearStudyKeyboard()
+	case "profile_pref_gender":
+		keyboard = createPrefGenderKeyboard()
+	}
+	sessions.SetKeyboard(chatID, keyboard)
+	sendMessageWithKeyboard(chatID, "✏️ *Editing Profile*\n──────────────\n\n"+field.Prompt, keyboard)
+}
+
+func isEditingProfile(userID int64) bool {
+	editing, _ := sessions.GetData(userID, "editing")
+	return editing == true
+}
+
+func profileCancelText(userID int64) string {
+	if isEditingProfile(userID) {
+		return "❌ *Cancelled*\n\nProfile unchanged."
+	}
+	return "❌ *Cancelled*\n\nProfile creation cancelled."
+}
+
+// finishProfileEdit saves the edited profile, keeping its creation date.
+func finishProfileEdit(userID int64, chatID int64) {
+	data := sessions.Data(userID)
+	sessions.ClearState(userID)
+
+	_, err := db.Exec(`
+		UPDATE blind_profiles
+		SET age = ?, years_on_campus = ?, year_of_study = ?, pref_gender = ?,
+		    pref_age_min = ?, pref_age_max = ?, last_updated = datetime('now')
+		WHERE user_id = ?`,
+		data["age"], data["years_on_campus"], data["year_of_study"], data["pref_gender"],
+		data["pref_age_min"], data["pref_age_max"], userID)
+	if err != nil {
+		log.Println("Error updating blind profile:", err)
+		sendMessage(chatID, "❌ *Error*\n\nCould not update your profile. Please try again.")
+		return
+	}
+
+	sendMessage(chatID, "✅ *Profile Updated*\n\nYour changes apply to your next match.")
+	showBlindProfile(userID, chatID)
+}
+
+func confirmProfileDeletion(userID int64, chatID int64) {
+	profile, err := getBlindProfile(userID)
+	if err != nil || !profile.ProfileSet {
+		sendMessage(chatID, "📋 *No Profile Found*\n\nThere is no blind connection profile to delete.")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID,
+		"🗑️ *Delete Profile?*\n──────────────\n\n"+
+			"Your blind connection profile will be removed and you will leave the matching queue.\n\n"+
+			"Your gender stays set. You can create a new profile any time with /blind.")
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑️ Delete", "deleteprofile:confirm"),
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Keep", "deleteprofile:cancel"),
+		),
+	)
+	bot.Send(msg)
+}
+
+func handleDeleteProfileCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 2 {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	userID := cb.From.ID
+	chatID := cb.Message.Chat.ID
+
+	if parts[1] != "confirm" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "↩️ Profile kept"))
+		bot.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "↩️ Profile kept."))
+		return
+	}
+
+	if sessions.InPair(userID) {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ End your current chat first"))
+		return
+	}
+
+	if sessions.CancelWaiting(userID) {
+		deleteQueueEntry(userID)
+	}
+
+	_, err := db.Exec("DELETE FROM blind_profiles WHERE user_id = ?", userID)
+	if err != nil {
+		log.Println("Error deleting blind profile:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error deleting profile"))
+		return
+	}
+
+	bot.Send(tgbotapi.NewCallback(cb.ID, "🗑️ Profile deleted"))
+	bot.Send(tgbotapi.NewEditMessageText(chatID, cb.Message.MessageID, "🗑️ Profile deleted."))
+
+	mainMenuKeyboard := createMainMenuKeyboard()
+	sessions.SetKeyboard(chatID, mainMenuKeyboard)
+	sendMessageWithKeyboard(chatID,
+		"🗑️ *Profile Deleted*\n\nYour blind connection profile is gone. Use /blind to create a new one.",
+		mainMenuKeyboard)
+}
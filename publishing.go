@@ -0,0 +1,422 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- PUBLISHING QUEUE -----------------
+
+// Approving a confession puts it in the queue (status queued). The publish
+// routine posts the oldest one whenever the window is open and the last post
+// is at least cfg.PublishInterval old, so a busy review session is spread
+// out over the day. Admins can skip the wait with Post now or /postnow.
+// A post that fails goes to the back of the queue; after
+// cfg.PublishMaxAttempts failures it is marked failed and the admins are told,
+// so one bad confession can't hold up the rest.
+
+var errNotQueued = errors.New("confession is not queued")
+
+// queueConfession marks a pending confession as approved but not yet posted.
+// It reports false if the confession was already reviewed.
+func queueConfession(confessionID int) (bool, error) {
+	result, err := db.Exec(`
+		UPDATE confessions
+		SET approved = 1, status = 'queued', queued_at = datetime('now')
+		WHERE id = ? AND status = 'pending'`, confessionID)
+	if err != nil {
+		return false, err
+	}
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
+
+func queuedCount() int {
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM confessions WHERE status = 'queued'").Scan(&count)
+	return count
+}
+
+// queuePosition returns where a queued confession sits in publishing order,
+// starting at 1. It reports false if the confession is no longer queued.
+func queuePosition(confessionID int) (int, bool) {
+	var position int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM confessions AS ahead, confessions AS c
+		WHERE c.id = ? AND c.status = 'queued' AND ahead.status = 'queued'
+		  AND (ahead.queued_at < c.queued_at OR (ahead.queued_at = c.queued_at AND ahead.id <= c.id))`,
+		confessionID).Scan(&position)
+	if err != nil {
+		log.Println("Error getting queue position:", err)
+		return 0, false
+	}
+	return position, position > 0
+}
+
+// publishConfession posts a queued confession to the channel and tells the
+// author. Only one caller can win a confession, so the scheduler and Post now
+// never post it twice. Admins can also retry a failed one this way.
+func publishConfession(confessionID int) error {
+	result, err := db.Exec(`
+		UPDATE confessions SET status = 'publishing'
+		WHERE id = ? AND status IN ('queued', 'failed')`, confessionID)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return errNotQueued
+	}
+
+	var userID int64
+	var confessionType, text, mediaID string
+	err = db.QueryRow(`
+		SELECT user_id, type, COALESCE(text, ''),
+		       CASE type WHEN 'voice' THEN COALESCE(voice_id, '') WHEN 'photo' THEN COALESCE(photo_id, '') ELSE '' END
+		FROM confessions WHERE id = ?`, confessionID).Scan(&userID, &confessionType, &text, &mediaID)
+	if err == nil {
+		var channelMsgID int
+		channelMsgID, err = postFrostedMirrorConfession(confessionID, confessionType, text, mediaID)
+		if err == nil {
+			_, err = db.Exec(`
+				UPDATE confessions
+				SET status = 'approved', posted_at = datetime('now'), channel_message_id = ?
+				WHERE id = ?`, channelMsgID, confessionID)
+			if err != nil {
+				log.Println("Error saving channel message ID:", err)
+			}
+			notifyConfessionPublished(userID, confessionType, confessionID)
+			return nil
+		}
+	}
+
+	recordPublishFailure(confessionID, err)
+	return err
+}
+
+// recordPublishFailure puts a confession that failed to post at the back of
+// the queue, or marks it failed once it has used up its attempts
+func recordPublishFailure(confessionID int, postErr error) {
+	var attempts int
+	err := db.QueryRow(`
+		UPDATE confessions
+		SET publish_attempts = publish_attempts + 1,
+		    status = CASE WHEN publish_attempts + 1 >= ? THEN 'failed' ELSE 'queued' END,
+		    queued_at = datetime('now')
+		WHERE id = ? AND status = 'publishing'
+		RETURNING publish_attempts`, cfg.PublishMaxAttempts, confessionID).Scan(&attempts)
+	if err != nil {
+		log.Println("Error recording publish failure:", err)
+		return
+	}
+	if attempts < cfg.PublishMaxAttempts {
+		return
+	}
+
+	log.Printf("Confession #%d failed to post %d times, taking it out of the queue", confessionID, attempts)
+	sendMessage(adminGroupID, fmt.Sprintf("⚠️ *Could not post #%d*\n\n"+
+		"It failed %d times and was taken out of the queue.\n\n`%s`\n\n"+
+		"Use `/postnow %d` to try again.", confessionID, attempts, postErr.Error(), confessionID))
+}
+
+func notifyConfessionPublished(userID int64, confessionType string, confessionID int) {
+	userMsg := tgbotapi.NewMessage(userID,
+		fmt.Sprintf("✅ *CONFESSION PUBLISHED*\n──────────────\n\n"+
+			"✨ *Your %s confession is now live*\n\n"+
+			"📜 *Confession ID:* #%d\n"+
+			"✅ *Status:* Published anonymously\n"+
+			"🎨 *Style:* Frosted mirror presentation\n"+
+			"💫 *People can react with emotional responses*\n"+
+			"💬 *Comments:* Viewable via comment button\n\n"+
+			"──────────────\n"+
+			"*Thank you for sharing.*",
+			confessionType, confessionID))
+	userMsg.ParseMode = "Markdown"
+	mainMenuKeyboard := createMainMenuKeyboard()
+	sessions.SetKeyboard(userID, mainMenuKeyboard)
+	userMsg.ReplyMarkup = mainMenuKeyboard
+	bot.Send(userMsg)
+	offerAuthorNotifications(userID)
+}
+
+func publishedAdminText(confessionType string, confessionID int, userID int64) string {
+	statusText := "✅ *TEXT APPROVED*"
+	if confessionType == "voice" {
+		statusText = "✅ *VOICE APPROVED*"
+	} else if confessionType == "photo" {
+		statusText = "✅ *PHOTO APPROVED*"
+	}
+
+	return fmt.Sprintf("%s #%d\n──────────────\n\n"+
+		"✨ *Published with frosted mirror style*\n\n"+
+		"👤 *Sender ID:* `%d`\n"+
+		"✅ *Status:* Posted to channel\n"+
+		"🎨 *Style:* Minimal, professional\n"+
+		"💬 *System:* Reaction & comment enabled\n"+
+		"🕐 *Time:* %s",
+		statusText, confessionID, userID, time.Now().Format("3:04 PM"))
+}
+
+func queuedAdminText(confessionID int, userID int64, position int, eta time.Time) string {
+	return fmt.Sprintf("⏳ *QUEUED* #%d\n──────────────\n\n"+
+		"✅ *Approved for the channel*\n\n"+
+		"👤 *Sender ID:* `%d`\n"+
+		"🗓️ *Position:* %d in queue\n"+
+		"🕐 *Expected:* %s\n\n"+
+		"Use 🚀 Post now to skip the queue.",
+		confessionID, userID, position, formatPublishTime(eta))
+}
+
+// editReviewCard replaces the text and buttons of a review message. Voice
+// confessions are reviewed on the voice message itself, so their caption is
+// edited instead.
+func editReviewCard(messageID int, confessionType string, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	if confessionType == "voice" {
+		editCaption := tgbotapi.NewEditMessageCaption(adminGroupID, messageID, text)
+		editCaption.ParseMode = "Markdown"
+		editCaption.ReplyMarkup = &keyboard
+		bot.Send(editCaption)
+		return
+	}
+
+	editMsg := tgbotapi.NewEditMessageTextAndMarkup(adminGroupID, messageID, text, keyboard)
+	editMsg.ParseMode = "Markdown"
+	bot.Send(editMsg)
+}
+
+func createQueuedAdminKeyboard(confessionID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚀 Post now", fmt.Sprintf("publish:now:%d", confessionID)),
+		),
+	)
+}
+
+func formatPublishTime(t time.Time) string {
+	now := time.Now()
+	if t.Sub(now) < time.Minute {
+		return "Next slot"
+	}
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("3:04 PM")
+	}
+	return t.Format("Jan 2, 3:04 PM")
+}
+
+// inPublishWindow reports whether t falls inside the daily window. A window
+// whose end is before its start runs past midnight; equal bounds mean all day.
+func inPublishWindow(t time.Time) bool {
+	start, end := cfg.publishStart, cfg.publishEnd
+	minute := t.Hour()*60 + t.Minute()
+	switch {
+	case start == end:
+		return true
+	case start < end:
+		return minute >= start && minute < end
+	default:
+		return minute >= start || minute < end
+	}
+}
+
+// nextWindowOpening returns t if the window is open then, otherwise the time
+// it next opens.
+func nextWindowOpening(t time.Time) time.Time {
+	if inPublishWindow(t) {
+		return t
+	}
+	opening := time.Date(t.Year(), t.Month(), t.Day(), cfg.publishStart/60, cfg.publishStart%60, 0, 0, t.Location())
+	if !opening.After(t) {
+		opening = opening.AddDate(0, 0, 1)
+	}
+	return opening
+}
+
+// lastPostTime returns when the channel last got a confession
+func lastPostTime() (time.Time, bool) {
+	var unix sql.NullInt64
+	db.QueryRow("SELECT CAST(strftime('%s', MAX(posted_at)) AS INTEGER) FROM confessions").Scan(&unix)
+	if !unix.Valid {
+		return time.Time{}, false
+	}
+	return time.Unix(unix.Int64, 0), true
+}
+
+// nextPublishTimes estimates when the next n queued confessions go out
+func nextPublishTimes(n int) []time.Time {
+	t := time.Now()
+	if last, ok := lastPostTime(); ok && last.Add(cfg.PublishInterval.Duration).After(t) {
+		t = last.Add(cfg.PublishInterval.Duration)
+	}
+
+	times := make([]time.Time, 0, n)
+	for i := 0; i < n; i++ {
+		t = nextWindowOpening(t)
+		times = append(times, t)
+		t = t.Add(cfg.PublishInterval.Duration)
+	}
+	return times
+}
+
+// publishDue reports whether a slot is open right now
+func publishDue(now time.Time) bool {
+	if !inPublishWindow(now) {
+		return false
+	}
+	last, ok := lastPostTime()
+	return !ok || !now.Before(last.Add(cfg.PublishInterval.Duration))
+}
+
+// publishNextQueued posts the oldest queued confession if a slot is open
+func publishNextQueued() {
+	if !publishDue(time.Now()) {
+		return
+	}
+
+	var confessionID int
+	err := db.QueryRow(`
+		SELECT id FROM confessions
+		WHERE status = 'queued'
+		ORDER BY queued_at, id
+		LIMIT 1`).Scan(&confessionID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("Error loading publishing queue:", err)
+		}
+		return
+	}
+
+	if err := publishConfession(confessionID); err != nil && err != errNotQueued {
+		log.Printf("Error publishing confession #%d: %v", confessionID, err)
+	}
+}
+
+func publishRoutine() {
+	// Confessions caught mid-post by a restart go back in the queue
+	db.Exec("UPDATE confessions SET status = 'queued' WHERE status = 'publishing'")
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		publishNextQueued()
+	}
+}
+
+// handlePublishCallback handles publish:now:<confession_id> in the admin group
+func handlePublishCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 3 || cb.Message == nil || cb.Message.Chat.ID != adminGroupID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	confessionID, err := strconv.Atoi(parts[2])
+	if err != nil || parts[1] != "now" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	if err := publishConfession(confessionID); err != nil {
+		if err == errNotQueued {
+			bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already posted"))
+			removeInlineKeyboard(cb)
+			return
+		}
+		log.Println("Error posting confession:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	var userID int64
+	var confessionType string
+	db.QueryRow("SELECT user_id, type FROM confessions WHERE id = ?", confessionID).Scan(&userID, &confessionType)
+
+	editReviewCard(cb.Message.MessageID, confessionType, publishedAdminText(confessionType, confessionID, userID),
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+
+	bot.Send(tgbotapi.NewCallback(cb.ID, "🚀 Posted"))
+}
+
+// handlePostNowCommand handles /postnow <confession_id>
+func handlePostNowCommand(chatID int64, args []string) {
+	if len(args) < 1 {
+		sendMessage(chatID, "ℹ️ *Usage:* `/postnow <confession_id>`")
+		return
+	}
+	confessionID, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		sendMessage(chatID, "❌ *Invalid confession ID*")
+		return
+	}
+
+	if err := publishConfession(confessionID); err != nil {
+		if err == errNotQueued {
+			sendMessage(chatID, fmt.Sprintf("ℹ️ *Confession #%d isn't in the queue*", confessionID))
+			return
+		}
+		log.Println("Error posting confession:", err)
+		sendMessage(chatID, fmt.Sprintf("❌ *Could not post #%d*\n\n`%s`", confessionID, err.Error()))
+		return
+	}
+	sendMessage(chatID, fmt.Sprintf("🚀 *Confession #%d posted*", confessionID))
+}
+
+// sendPublishingQueue lists queued confessions with their expected times
+func sendPublishingQueue(chatID int64) {
+	rows, err := db.Query(`
+		SELECT id, type, COALESCE(text, '')
+		FROM confessions
+		WHERE status = 'queued'
+		ORDER BY queued_at, id
+		LIMIT 20`)
+	if err != nil {
+		log.Println("Error loading publishing queue:", err)
+		sendMessage(chatID, "❌ *Error loading the queue*")
+		return
+	}
+	defer rows.Close()
+
+	type queued struct {
+		id      int
+		preview string
+	}
+	var entries []queued
+	for rows.Next() {
+		var id int
+		var confessionType, text string
+		if err := rows.Scan(&id, &confessionType, &text); err != nil {
+			log.Println("Error scanning queued confession:", err)
+			continue
+		}
+		preview := truncateText(text, 50)
+		switch confessionType {
+		case "voice":
+			preview = "🎤 Voice confession"
+		case "photo":
+			preview = "📸 Photo · " + truncateText(text, 40)
+		}
+		entries = append(entries, queued{id, preview})
+	}
+
+	if len(entries) == 0 {
+		sendMessage(chatID, "✅ *Publishing queue is empty*\n\nApproved confessions go out as soon as there's a slot.")
+		return
+	}
+
+	times := nextPublishTimes(len(entries))
+	var lines []string
+	for i, e := range entries {
+		lines = append(lines, fmt.Sprintf("*#%d* · %s\n%s", e.id, formatPublishTime(times[i]), escapeMarkdown(e.preview)))
+	}
+
+	sendMessage(chatID, fmt.Sprintf("🗓️ *PUBLISHING QUEUE* (%d)\n──────────────\n\n%s\n\n"+
+		"──────────────\n"+
+		"⏱️ One every %s, %s–%s\n"+
+		"Use `/postnow <id>` to skip the queue.",
+		queuedCount(), strings.Join(lines, "\n\n"),
+		formatWait(cfg.PublishInterval.Duration), cfg.PublishWindowStart, cfg.PublishWindowEnd))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- CONFESSION QUOTAS -----------------
+
+// confessionActions lists the limits a confession of the given type counts against
+func confessionActions(confessionType string) []string {
+	actions := []string{ActionConfession, ActionConfessionHourly}
+	if confessionType == "voice" {
+		actions = append(actions, ActionVoiceConfession)
+	}
+	return actions
+}
+
+// allowConfession checks the global review queue cap and every per-user
+// quota for the confession type, telling the user why if one is exhausted.
+func allowConfession(userID int64, chatID int64, confessionType string) bool {
+	if pending := pendingReviewCount(); pending >= cfg.MaxPendingConfessions {
+		sendMessage(chatID,
+			"🚦 *Review Queue Full*\n\n"+
+				"Our admins have a lot of confessions to review right now.\n"+
+				"Please try again a little later.")
+		return false
+	}
+
+	for _, action := range confessionActions(confessionType) {
+		if !allowAction(userID, chatID, action) {
+			return false
+		}
+	}
+	return true
+}
+
+func recordConfession(userID int64, confessionType string) {
+	for _, action := range confessionActions(confessionType) {
+		recordAction(userID, action)
+	}
+}
+
+// pendingReviewCount counts confessions waiting for admins, including voice
+// confessions still being anonymized.
+func pendingReviewCount() int {
+	var count int
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM confessions WHERE status = 'pending') +
+			(SELECT COUNT(*) FROM voice_jobs
+			 WHERE kind = 'confession' AND confession_id = 0 AND status IN ('queued', 'processing'))`).Scan(&count)
+	if err != nil {
+		log.Println("Error counting pending confessions:", err)
+	}
+	return count
+}
+
+// quotaLine renders one limit for /status, e.g. "Quota: 3/5 left"
+func quotaLine(userID int64, label string, action string) string {
+	limit, ok := effectiveLimit(userID, action)
+	if !ok || limit.Unlimited() {
+		return fmt.Sprintf("• %s: ✅ Unlimited", label)
+	}
+
+	remaining := limit.Max - countActions(userID, action, limit.Window.Duration)
+	if remaining > 0 {
+		return fmt.Sprintf("• %s: %d/%d left", label, remaining, limit.Max)
+	}
+
+	_, wait := checkRateLimit(userID, action)
+	return fmt.Sprintf("• %s: ⏳ 0/%d, next in %s", label, limit.Max, formatWait(wait))
+}
+
+func confessionQuotaText(userID int64) string {
+	return strings.Join([]string{
+		quotaLine(userID, "Quota", ActionConfession),
+		quotaLine(userID, "Burst", ActionConfessionHourly),
+		quotaLine(userID, "Voice quota", ActionVoiceConfession),
+	}, "\n")
+}
+
+// ----- Admin overrides -----
+
+// handleSetLimitCommand handles /setlimit <user_id> <action> <max/window|unlimited|reset>
+func handleSetLimitCommand(msg *tgbotapi.Message, args []string) {
+	chatID := msg.Chat.ID
+	usage := "ℹ️ *Usage:* `/setlimit <user_id> <action> <max/window | unlimited | reset>`\n\n" +
+		"*Actions:* " + strings.Join(rateLimitActions(), ", ")
+
+	if len(args) < 3 {
+		sendMessage(chatID, usage)
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		sendMessage(chatID, "❌ *Invalid user ID*")
+		return
+	}
+
+	action := args[1]
+	base, ok := cfg.RateLimits[action]
+	if !ok {
+		sendMessage(chatID, usage)
+		return
+	}
+
+	switch value := strings.ToLower(args[2]); value {
+	case "reset":
+		_, err = db.Exec("DELETE FROM rate_limit_overrides WHERE user_id = ? AND action = ?", userID, action)
+
+	case "unlimited":
+		err = saveLimitOverride(userID, action, RateLimit{Max: -1, Window: base.Window}, msg.From.ID)
+
+	default:
+		maxText, windowText, found := strings.Cut(value, "/")
+		maxCount, convErr := strconv.Atoi(maxText)
+		if !found || convErr != nil || maxCount < 1 {
+			sendMessage(chatID, usage)
+			return
+		}
+		window, parseErr := parseBanDuration(windowText)
+		if parseErr != nil {
+			sendMessage(chatID, usage)
+			return
+		}
+		err = saveLimitOverride(userID, action, RateLimit{Max: maxCount, Window: Duration{window}}, msg.From.ID)
+	}
+
+	if err != nil {
+		log.Println("Error saving rate limit override:", err)
+		sendMessage(chatID, "❌ *Error saving limit*")
+		return
+	}
+
+	sendUserLimits(chatID, userID)
+}
+
+func saveLimitOverride(userID int64, action string, limit RateLimit, setBy int64) error {
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO rate_limit_overrides (user_id, action, max_count, window_seconds, set_by)
+		VALUES (?, ?, ?, ?, ?)`,
+		userID, action, limit.Max, int(limit.Window.Seconds()), setBy)
+	return err
+}
+
+// sendUserLimits shows every limit as it applies to userID, marking overrides
+func sendUserLimits(chatID int64, userID int64) {
+	var lines []string
+	for _, action := range rateLimitActions() {
+		limit, _ := effectiveLimit(userID, action)
+
+		var overridden int
+		db.QueryRow("SELECT COUNT(*) FROM rate_limit_overrides WHERE user_id = ? AND action = ?", userID, action).Scan(&overridden)
+		marker := ""
+		if overridden > 0 {
+			marker = " ✏️"
+		}
+
+		if limit.Unlimited() {
+			lines = append(lines, fmt.Sprintf("• `%s`: unlimited%s", action, marker))
+			continue
+		}
+		used := countActions(userID, action, limit.Window.Duration)
+		lines = append(lines, fmt.Sprintf("• `%s`: %d/%d per %s%s",
+			action, used, limit.Max, formatWait(limit.Window.Duration), marker))
+	}
+
+	sendMessage(chatID, fmt.Sprintf("🚦 *LIMITS FOR* `%d`\n──────────────\n\n%s\n\n"+
+		"✏️ = admin override\n"+
+		"📥 *Review queue:* %d/%d",
+		userID, strings.Join(lines, "\n"), pendingReviewCount(), cfg.MaxPendingConfessions))
+}
+
+// rateLimitActions returns the configured actions in a stable order
+func rateLimitActions() []string {
+	var actions []string
+	for _, action := range []string{ActionConfession, ActionConfessionHourly, ActionVoiceConfession, ActionComment, ActionAdminContact} {
+		if _, ok := cfg.RateLimits[action]; ok {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
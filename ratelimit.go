@@ -0,0 +1,194 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ----------------- RATE LIMITS -----------------
+
+// Rate-limited actions; each has a sliding window in cfg.RateLimits
+const (
+	ActionAdminContact     = "admin_contact"
+	ActionConfession       = "confession"
+	ActionConfessionHourly = "confession_hourly"
+	ActionVoiceConfession  = "voice_confession"
+	ActionComment          = "comment"
+)
+
+// RateLimit allows Max actions in any Window-long stretch of time. A
+// negative Max (only used in per-user overrides) means unlimited.
+type RateLimit struct {
+	Max    int      `json:"max"`
+	Window Duration `json:"window"`
+}
+
+func (l RateLimit) Unlimited() bool {
+	return l.Max < 0
+}
+
+// effectiveLimit returns the admin override for userID if there is one,
+// otherwise the configured limit.
+func effectiveLimit(userID int64, action string) (RateLimit, bool) {
+	var maxCount, windowSeconds int
+	err := db.QueryRow(`
+		SELECT max_count, window_seconds FROM rate_limit_overrides
+		WHERE user_id = ? AND action = ?`, userID, action).Scan(&maxCount, &windowSeconds)
+	if err == nil {
+		return RateLimit{Max: maxCount, Window: Duration{time.Duration(windowSeconds) * time.Second}}, true
+	}
+	if err != sql.ErrNoRows {
+		log.Println("Error loading rate limit override:", err)
+	}
+
+	limit, ok := cfg.RateLimits[action]
+	return limit, ok
+}
+
+// countActions counts userID's actions inside the limit's window
+func countActions(userID int64, action string, window time.Duration) int {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM rate_limit_events
+		WHERE user_id = ? AND action = ? AND created_at > datetime('now', ?)`,
+		userID, action, windowOffset(window)).Scan(&count)
+	if err != nil {
+		log.Println("Error counting rate limit events:", err)
+	}
+	return count
+}
+
+// checkRateLimit reports whether userID may perform action now and, if not,
+// how long until the oldest action in the window slides out of it.
+func checkRateLimit(userID int64, action string) (bool, time.Duration) {
+	limit, ok := effectiveLimit(userID, action)
+	if !ok || limit.Unlimited() {
+		return true, 0
+	}
+
+	// The Max-th most recent action is the one that has to expire first
+	var oldest time.Time
+	err := db.QueryRow(`
+		SELECT created_at FROM rate_limit_events
+		WHERE user_id = ? AND action = ? AND created_at > datetime('now', ?)
+		ORDER BY created_at DESC
+		LIMIT 1 OFFSET ?`,
+		userID, action, windowOffset(limit.Window.Duration), limit.Max-1).Scan(&oldest)
+	if err == sql.ErrNoRows {
+		return true, 0
+	}
+	if err != nil {
+		log.Println("Error checking rate limit:", err)
+		return true, 0
+	}
+
+	wait := time.Until(oldest.Add(limit.Window.Duration))
+	if wait <= 0 {
+		return true, 0
+	}
+	return false, wait
+}
+
+func recordAction(userID int64, action string) {
+	_, err := db.Exec(`
+		INSERT INTO rate_limit_events (user_id, action)
+		VALUES (?, ?)`, userID, action)
+	if err != nil {
+		log.Println("Error recording rate limit event:", err)
+	}
+}
+
+// allowAction checks the limit and tells the user exactly when they can try
+// again if they are over it.
+func allowAction(userID int64, chatID int64, action string) bool {
+	allowed, wait := checkRateLimit(userID, action)
+	if allowed {
+		return true
+	}
+
+	limit, _ := effectiveLimit(userID, action)
+	sendMessage(chatID,
+		fmt.Sprintf("⏳ *Rate Limited*\n\n"+
+			"You can do this %s every %s.\n\n"+
+			"🕐 *Try again in:* %s\n"+
+			"📅 *Available at:* %s",
+			timesText(limit.Max), formatWait(limit.Window.Duration),
+			formatWait(wait), time.Now().Add(wait).Format("Jan 2, 3:04 PM")))
+	return false
+}
+
+func timesText(n int) string {
+	switch n {
+	case 1:
+		return "once"
+	case 2:
+		return "twice"
+	}
+	return fmt.Sprintf("%d times", n)
+}
+
+// formatWait renders a duration as its two largest units, e.g. "2 days 3 hours"
+func formatWait(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+	}
+
+	var parts []string
+	for _, unit := range units {
+		if n := int(d / unit.size); n > 0 {
+			if n == 1 {
+				parts = append(parts, "1 "+unit.name)
+			} else {
+				parts = append(parts, fmt.Sprintf("%d %ss", n, unit.name))
+			}
+			d -= time.Duration(n) * unit.size
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+
+	if len(parts) == 2 {
+		return parts[0] + " " + parts[1]
+	}
+	return parts[0]
+}
+
+func windowOffset(window time.Duration) string {
+	return fmt.Sprintf("-%d seconds", int(window.Seconds()))
+}
+
+func cleanupRateLimitEvents() {
+	// Events older than the longest window can never count again; per-user
+	// overrides may set a longer window than any in the config
+	var longest time.Duration
+	for _, limit := range cfg.RateLimits {
+		if limit.Window.Duration > longest {
+			longest = limit.Window.Duration
+		}
+	}
+	var overrideSeconds sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(window_seconds) FROM rate_limit_overrides").Scan(&overrideSeconds); err != nil {
+		log.Println("Error getting longest rate limit override:", err)
+		return
+	}
+	if override := time.Duration(overrideSeconds.Int64) * time.Second; override > longest {
+		longest = override
+	}
+
+	_, err := db.Exec("DELETE FROM rate_limit_events WHERE created_at < datetime('now', ?)", windowOffset(longest))
+	if err != nil {
+		log.Println("Error cleaning up rate limit events:", err)
+	}
+}
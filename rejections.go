@@ -0,0 +1,248 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- REJECTION REASONS -----------------
+
+// Reject opens a reason picker on the review message. The chosen preset, or
+// a custom note an admin writes, is stored in confessions.reject_reason and
+// passed on to the author.
+
+type rejectReason struct {
+	Key   string
+	Label string
+	Text  string
+}
+
+var rejectReasons = []rejectReason{
+	{"personal", "🪪 Personal info", "It contains personal or identifying information"},
+	{"hate", "🚫 Hate / harassment", "It contains hateful or harassing content"},
+	{"spam", "📢 Spam", "It looks like spam or advertising"},
+	{"duplicate", "♻️ Duplicate", "It repeats a confession that was already posted"},
+	{"offtopic", "🧭 Off-topic", "It isn't a confession or doesn't fit the channel"},
+}
+
+// rejectCustomMaxLength keeps custom notes short enough for one message
+const rejectCustomMaxLength = 300
+
+func createRejectReasonKeyboard(confessionID int) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, reason := range rejectReasons {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(reason.Label,
+			fmt.Sprintf("reject_reason:%d:%s", confessionID, reason.Key)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData("✍️ Custom reason",
+		fmt.Sprintf("reject_reason:%d:custom", confessionID)))
+	rows = append(rows, row)
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅️ Back", fmt.Sprintf("reject_reason:%d:back", confessionID))))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleRejectReasonCallback handles reject_reason:<confession_id>:<key>
+func handleRejectReasonCallback(parts []string, cb *tgbotapi.CallbackQuery) {
+	if len(parts) < 3 || cb.Message == nil || cb.Message.Chat.ID != adminGroupID {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	confessionID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+
+	var confessionType, status string
+	err = db.QueryRow("SELECT type, status FROM confessions WHERE id = ?", confessionID).Scan(&confessionType, &status)
+	if err != nil {
+		log.Println("Error getting confession:", err)
+		bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+		return
+	}
+	if status != "pending" {
+		bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already reviewed"))
+		return
+	}
+
+	switch parts[2] {
+	case "back":
+		bot.Send(tgbotapi.NewEditMessageReplyMarkup(cb.Message.Chat.ID, cb.Message.MessageID,
+			createAdminApprovalKeyboard(confessionID, confessionType)))
+		bot.Send(tgbotapi.NewCallback(cb.ID, ""))
+
+	case "custom":
+		sessions.StartStep(cb.From.ID, "reject_reason", map[string]interface{}{
+			"confession_id": confessionID,
+			"message_id":    cb.Message.MessageID,
+		})
+
+		// ForceReply lets the note reach the bot even with group privacy mode on
+		prompt := tgbotapi.NewMessage(adminGroupID,
+			fmt.Sprintf("✍️ [%s](tg://user?id=%d), reply to this message with the reason for rejecting #%d "+
+				"(max %d characters).\n\nThe author will see it. Send /cancel to stop.",
+				escapeMarkdown(cb.From.FirstName), cb.From.ID, confessionID, rejectCustomMaxLength))
+		prompt.ParseMode = "Markdown"
+		prompt.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+		sent, err := bot.Send(prompt)
+		if err != nil {
+			log.Println("Error sending reject reason prompt:", err)
+			sessions.ClearState(cb.From.ID)
+			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+			return
+		}
+		// Only a reply to this prompt counts as the reason
+		sessions.SetData(cb.From.ID, "prompt_id", sent.MessageID)
+
+		bot.Send(tgbotapi.NewCallback(cb.ID, "✍️ Write the reason"))
+
+	default:
+		var reason string
+		for _, r := range rejectReasons {
+			if r.Key == parts[2] {
+				reason = r.Text
+			}
+		}
+		if reason == "" {
+			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Unknown reason"))
+			return
+		}
+
+		if err := rejectConfession(confessionID, reason, cb.Message.MessageID); err != nil {
+			if err == sql.ErrNoRows {
+				bot.Send(tgbotapi.NewCallback(cb.ID, "ℹ️ Already reviewed"))
+				return
+			}
+			log.Println("Error rejecting confession:", err)
+			bot.Send(tgbotapi.NewCallback(cb.ID, "❌ Error"))
+			return
+		}
+		bot.Send(tgbotapi.NewCallback(cb.ID, "✅ Rejected"))
+	}
+}
+
+// handleRejectReasonReply takes an admin's custom rejection note. Only a
+// reply to the prompt is used; other chatter in the group is left alone.
+func handleRejectReasonReply(adminID int64, chatID int64, msg *tgbotapi.Message) {
+	rawConfessionID, _ := sessions.GetData(adminID, "confession_id")
+	confessionID, _ := rawConfessionID.(int)
+	rawMessageID, _ := sessions.GetData(adminID, "message_id")
+	messageID, _ := rawMessageID.(int)
+	rawPromptID, _ := sessions.GetData(adminID, "prompt_id")
+	promptID, _ := rawPromptID.(int)
+
+	// Any command ends it; /cancel@BotName is the usual form in groups, and
+	// other commands still run instead of becoming the reason
+	if msg.IsCommand() || msg.Text == "❌ Cancel" || chatID != adminGroupID {
+		sessions.ClearState(adminID)
+		sendMessage(chatID, fmt.Sprintf("❌ *Rejection cancelled* (#%d)", confessionID))
+		if msg.IsCommand() && msg.Command() != "cancel" {
+			handleCommand(msg)
+		}
+		return
+	}
+	if msg.ReplyToMessage == nil || msg.ReplyToMessage.MessageID != promptID {
+		return
+	}
+	sessions.ClearState(adminID)
+
+	reason := strings.TrimSpace(msg.Text)
+	if reason == "" {
+		sendMessage(chatID, "❌ *Text Only*\n\nPress ✍️ Custom reason again and answer with text.")
+		return
+	}
+	if utf8.RuneCountInString(reason) > rejectCustomMaxLength {
+		sendMessage(chatID, fmt.Sprintf("📏 *Too Long*\n\nKeep the reason under %d characters and press ✍️ Custom reason again.",
+			rejectCustomMaxLength))
+		return
+	}
+
+	if err := rejectConfession(confessionID, reason, messageID); err != nil {
+		if err == sql.ErrNoRows {
+			sendMessage(chatID, fmt.Sprintf("ℹ️ *Confession #%d was already reviewed*", confessionID))
+			return
+		}
+		log.Println("Error rejecting confession:", err)
+		sendMessage(chatID, "❌ *Error rejecting confession*")
+		return
+	}
+	sendMessage(chatID, fmt.Sprintf("✅ *Confession #%d rejected*", confessionID))
+}
+
+// rejectConfession rejects a pending confession with reason, updates the
+// review message and tells the author. It returns sql.ErrNoRows if the
+// confession was no longer pending.
+func rejectConfession(confessionID int, reason string, adminMessageID int) error {
+	var confession Confession
+	err := db.QueryRow(`
+		SELECT id, user_id, type, date
+		FROM confessions WHERE id = ?`, confessionID).Scan(
+		&confession.ID, &confession.UserID, &confession.Type, &confession.Date)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`
+		UPDATE confessions
+		SET approved = 0, status = 'rejected', reject_reason = ?
+		WHERE id = ? AND status = 'pending'`, reason, confessionID)
+	if err != nil {
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return sql.ErrNoRows
+	}
+
+	// Update admin message; voice confessions are reviewed on the voice
+	// message itself, so it's the caption that changes
+	adminText := fmt.Sprintf("❌ *REJECTED* #%d\n──────────────\n\n"+
+		"👤 *Sender ID:* `%d`\n"+
+		"❌ *Status:* Not approved\n"+
+		"📋 *Reason:* %s\n"+
+		"📊 *Type:* %s\n"+
+		"🕐 *Time:* %s",
+		confessionID, confession.UserID, escapeMarkdown(reason), strings.Title(confession.Type),
+		time.Now().Format("3:04 PM"))
+	noButtons := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if confession.Type == "voice" {
+		editCaption := tgbotapi.NewEditMessageCaption(adminGroupID, adminMessageID, adminText)
+		editCaption.ParseMode = "Markdown"
+		editCaption.ReplyMarkup = &noButtons
+		bot.Send(editCaption)
+	} else {
+		editMsg := tgbotapi.NewEditMessageTextAndMarkup(adminGroupID, adminMessageID, adminText, noButtons)
+		editMsg.ParseMode = "Markdown"
+		bot.Send(editMsg)
+	}
+
+	// Notify user
+	userMsg := tgbotapi.NewMessage(confession.UserID,
+		fmt.Sprintf("❌ *CONFESSION REVIEWED*\n──────────────\n\n"+
+			"📋 *Status:* Not Approved\n\n"+
+			"⚠️ *Reason:* %s\n\n"+
+			"💡 *Tips:*\n"+
+			"• Keep it respectful\n"+
+			"• Be positive\n"+
+			"• Avoid personal info\n\n"+
+			"──────────────\n"+
+			"✨ *You can try again now!*", escapeMarkdown(reason)))
+	userMsg.ParseMode = "Markdown"
+	mainMenuKeyboard := createMainMenuKeyboard()
+	sessions.SetKeyboard(confession.UserID, mainMenuKeyboard)
+	userMsg.ReplyMarkup = mainMenuKeyboard
+	bot.Send(userMsg)
+	return nil
+}
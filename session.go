@@ -0,0 +1,392 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- SESSION STORE -----------------
+
+// SessionStore owns all in-memory conversation state. Every handler and the
+// cleanup routine go through its methods so access is always synchronized.
+// Methods never call out to Telegram while holding the lock.
+type SessionStore struct {
+	mu                sync.RWMutex
+	states            map[int64]*UserState
+	confessionWaiting map[int64]string // userID -> confessionType
+	commentWaiting    map[int64]CommentData
+	pairs             map[int64]BlindChatPair
+	waiting           map[int64]time.Time // userID -> joined the matchmaking pool
+	keyboards         map[int64]tgbotapi.ReplyKeyboardMarkup
+}
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		states:            make(map[int64]*UserState),
+		confessionWaiting: make(map[int64]string),
+		commentWaiting:    make(map[int64]CommentData),
+		pairs:             make(map[int64]BlindChatPair),
+		waiting:           make(map[int64]time.Time),
+		keyboards:         make(map[int64]tgbotapi.ReplyKeyboardMarkup),
+	}
+}
+
+// ----- User steps -----
+
+// Touch creates an idle state for the user if needed and marks them active.
+func (s *SessionStore) Touch(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[userID]
+	if !ok {
+		state = &UserState{
+			Step: "idle",
+			Data: make(map[string]interface{}),
+		}
+		s.states[userID] = state
+	}
+	state.LastActive = time.Now()
+}
+
+// Step returns the user's current step, or "idle" if they have no state.
+func (s *SessionStore) Step(userID int64) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if state, ok := s.states[userID]; ok {
+		return state.Step
+	}
+	return "idle"
+}
+
+// StartStep replaces the user's state with a fresh one at the given step.
+func (s *SessionStore) StartStep(userID int64, step string, data map[string]interface{}) {
+	copied := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		copied[k] = v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[userID] = &UserState{
+		Step:       step,
+		Data:       copied,
+		LastActive: time.Now(),
+	}
+}
+
+// SetStep moves an existing state to a new step, creating one if needed.
+func (s *SessionStore) SetStep(userID int64, step string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.stateLocked(userID)
+	state.Step = step
+	state.LastActive = time.Now()
+}
+
+func (s *SessionStore) SetData(userID int64, key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stateLocked(userID).Data[key] = value
+}
+
+func (s *SessionStore) GetData(userID int64, key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.states[userID]
+	if !ok {
+		return nil, false
+	}
+	value, ok := state.Data[key]
+	return value, ok
+}
+
+// Data returns a copy of the user's step data.
+func (s *SessionStore) Data(userID int64) map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	copied := make(map[string]interface{})
+	if state, ok := s.states[userID]; ok {
+		for k, v := range state.Data {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+func (s *SessionStore) ClearState(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, userID)
+}
+
+func (s *SessionStore) stateLocked(userID int64) *UserState {
+	state, ok := s.states[userID]
+	if !ok {
+		state = &UserState{
+			Step:       "idle",
+			Data:       make(map[string]interface{}),
+			LastActive: time.Now(),
+		}
+		s.states[userID] = state
+	}
+	return state
+}
+
+// ----- Confession waiting -----
+
+// ConfessionType returns the confession type the user is composing, or "".
+func (s *SessionStore) ConfessionType(userID int64) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.confessionWaiting[userID]
+}
+
+func (s *SessionStore) SetConfessionType(userID int64, confessionType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.confessionWaiting[userID] = confessionType
+}
+
+func (s *SessionStore) ClearConfessionType(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.confessionWaiting, userID)
+}
+
+// ----- Comment waiting -----
+
+func (s *SessionStore) Comment(userID int64) (CommentData, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.commentWaiting[userID]
+	return data, ok
+}
+
+func (s *SessionStore) SetComment(userID int64, data CommentData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commentWaiting[userID] = data
+}
+
+func (s *SessionStore) ClearComment(userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.commentWaiting, userID)
+}
+
+// ----- Blind chat pairs -----
+
+func (s *SessionStore) Partner(userID int64) (BlindChatPair, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	partner, ok := s.pairs[userID]
+	return partner, ok
+}
+
+func (s *SessionStore) InPair(userID int64) bool {
+	_, ok := s.Partner(userID)
+	return ok
+}
+
+// Pair links two users. Each side stores what it knows about the other, and
+// neither user is left in the waiting pool.
+func (s *SessionStore) Pair(userID int64, userSide BlindChatPair, partnerID int64, partnerSide BlindChatPair) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pairs[userID] = userSide
+	s.pairs[partnerID] = partnerSide
+	delete(s.waiting, userID)
+	delete(s.waiting, partnerID)
+}
+
+// Unpair removes both sides of the user's pair and returns the partner.
+func (s *SessionStore) Unpair(userID int64) (BlindChatPair, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	partner, ok := s.pairs[userID]
+	if !ok {
+		return BlindChatPair{}, false
+	}
+	delete(s.pairs, userID)
+	delete(s.pairs, partner.PartnerID)
+	return partner, true
+}
+
+// ----- Waiting pool -----
+
+// JoinWaiting adds the user to the matchmaking pool. Re-joining keeps the
+// original join time so nobody loses their place by searching again.
+func (s *SessionStore) JoinWaiting(userID int64, joinedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.waiting[userID]; !ok {
+		s.waiting[userID] = joinedAt
+	}
+}
+
+func (s *SessionStore) IsWaiting(userID int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.waiting[userID]
+	return ok
+}
+
+// WaitingUsers returns a snapshot of the pool with each user's join time.
+func (s *SessionStore) WaitingUsers() map[int64]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[int64]time.Time, len(s.waiting))
+	for userID, joinedAt := range s.waiting {
+		snapshot[userID] = joinedAt
+	}
+	return snapshot
+}
+
+// CancelWaiting removes the user from the pool and reports whether they were in it.
+// Only one caller can win the removal.
+func (s *SessionStore) CancelWaiting(userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.waiting[userID]; !ok {
+		return false
+	}
+	delete(s.waiting, userID)
+	return true
+}
+
+// ClaimPartner takes a candidate out of the pool for a searcher in one step.
+// A searcher who was already waiting must still be; if another searcher
+// claimed them in the meantime, searcherFree is false and nothing changes.
+// On a successful claim both leave the pool.
+func (s *SessionStore) ClaimPartner(searcherID int64, searcherWaiting bool, candidateID int64) (claimed bool, searcherFree bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, paired := s.pairs[searcherID]; paired {
+		return false, false
+	}
+	if _, ok := s.waiting[searcherID]; searcherWaiting && !ok {
+		return false, false
+	}
+	if _, ok := s.waiting[candidateID]; !ok {
+		return false, true
+	}
+	delete(s.waiting, candidateID)
+	delete(s.waiting, searcherID)
+	return true, true
+}
+
+// ----- Keyboards -----
+
+func (s *SessionStore) Keyboard(chatID int64) (tgbotapi.ReplyKeyboardMarkup, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keyboard, ok := s.keyboards[chatID]
+	return keyboard, ok
+}
+
+func (s *SessionStore) SetKeyboard(chatID int64, keyboard tgbotapi.ReplyKeyboardMarkup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keyboards[chatID] = keyboard
+}
+
+func (s *SessionStore) ClearKeyboard(chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keyboards, chatID)
+}
+
+// ----- Expiry -----
+
+// ExpireStates drops user steps and confession drafts idle longer than maxIdle.
+func (s *SessionStore) ExpireStates(maxIdle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for userID, state := range s.states {
+		if now.Sub(state.LastActive) > maxIdle {
+			delete(s.states, userID)
+			delete(s.confessionWaiting, userID)
+		}
+	}
+}
+
+// ExpireWaiting removes pool members who went idle or lost their state and
+// returns them.
+func (s *SessionStore) ExpireWaiting(maxIdle time.Duration) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []int64
+	for userID := range s.waiting {
+		state, exists := s.states[userID]
+		if !exists || time.Since(state.LastActive) > maxIdle {
+			delete(s.waiting, userID)
+			expired = append(expired, userID)
+		}
+	}
+	return expired
+}
+
+// ExpireComments drops stale comment sessions and returns the users that were
+// still composing a comment so the caller can tell them.
+func (s *SessionStore) ExpireComments(maxIdle time.Duration) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []int64
+	now := time.Now()
+	for userID, data := range s.commentWaiting {
+		state, exists := s.states[userID]
+		if exists && now.Sub(state.LastActive) <= maxIdle {
+			continue
+		}
+		if exists && data.WaitingForComment {
+			expired = append(expired, userID)
+		}
+		delete(s.commentWaiting, userID)
+	}
+	return expired
+}
+
+// ExpireKeyboards forgets keyboards for chats whose user is idle or gone.
+func (s *SessionStore) ExpireKeyboards(maxIdle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for chatID := range s.keyboards {
+		state, exists := s.states[chatID]
+		if !exists || now.Sub(state.LastActive) > maxIdle {
+			delete(s.keyboards, chatID)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Run with -race: every test drives the store from many goroutines at once.
+
+const (
+	sessionTestWorkers = 32
+	sessionTestRounds  = 200
+	sessionTestUsers   = 16
+)
+
+func runConcurrently(t *testing.T, work func(worker, round int)) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for w := 0; w < sessionTestWorkers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for r := 0; r < sessionTestRounds; r++ {
+				work(worker, r)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func TestSessionStoreStepsAndData(t *testing.T) {
+	s := NewSessionStore()
+
+	runConcurrently(t, func(worker, round int) {
+		userID := int64((worker + round) % sessionTestUsers)
+		s.Touch(userID)
+		switch round % 5 {
+		case 0:
+			s.SetStep(userID, "profile_age")
+		case 1:
+			s.SetData(userID, "age", round)
+		case 2:
+			s.GetData(userID, "age")
+			s.Step(userID)
+		case 3:
+			for range s.Data(userID) {
+			}
+		case 4:
+			s.StartStep(userID, "appeal", map[string]interface{}{"round": round})
+		}
+	})
+
+	for userID := int64(0); userID < sessionTestUsers; userID++ {
+		if step := s.Step(userID); step == "idle" {
+			t.Errorf("user %d lost their state", userID)
+		}
+	}
+}
+
+func TestSessionStorePairing(t *testing.T) {
+	s := NewSessionStore()
+
+	runConcurrently(t, func(worker, round int) {
+		userID := int64(worker*2) % sessionTestUsers
+		partnerID := userID + 1
+		switch round % 4 {
+		case 0:
+			s.JoinWaiting(userID, time.Now())
+			s.JoinWaiting(partnerID, time.Now())
+		case 1:
+			s.Pair(userID, BlindChatPair{PartnerID: partnerID}, partnerID, BlindChatPair{PartnerID: userID})
+		case 2:
+			if partner, ok := s.Partner(userID); ok && partner.PartnerID != partnerID {
+				t.Errorf("user %d paired with %d, want %d", userID, partner.PartnerID, partnerID)
+			}
+			s.InPair(partnerID)
+			s.WaitingUsers()
+		case 3:
+			s.Unpair(userID)
+		}
+	})
+
+	// Pairs always come in twos
+	for userID := int64(0); userID < sessionTestUsers; userID++ {
+		partner, ok := s.Partner(userID)
+		if !ok {
+			continue
+		}
+		back, ok := s.Partner(partner.PartnerID)
+		if !ok || back.PartnerID != userID {
+			t.Errorf("pair %d -> %d is one-sided", userID, partner.PartnerID)
+		}
+	}
+}
+
+func TestSessionStoreCancelWaitingHasOneWinner(t *testing.T) {
+	s := NewSessionStore()
+
+	for round := 0; round < sessionTestRounds; round++ {
+		userID := int64(round)
+		s.JoinWaiting(userID, time.Now())
+
+		var winners int32
+		var wg sync.WaitGroup
+		for w := 0; w < sessionTestWorkers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if s.CancelWaiting(userID) {
+					atomic.AddInt32(&winners, 1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if winners != 1 {
+			t.Fatalf("round %d: %d goroutines claimed the same user", round, winners)
+		}
+	}
+}
+
+func TestSessionStoreClaimPartnerClaimsEachUserOnce(t *testing.T) {
+	for round := 0; round < sessionTestRounds; round++ {
+		s := NewSessionStore()
+		for userID := int64(0); userID < sessionTestUsers; userID++ {
+			s.JoinWaiting(userID, time.Now())
+		}
+
+		// Every waiting user searches at once and tries everyone else
+		var mu sync.Mutex
+		claims := make(map[int64]int)
+		var wg sync.WaitGroup
+		for searcherID := int64(0); searcherID < sessionTestUsers; searcherID++ {
+			wg.Add(1)
+			go func(searcherID int64) {
+				defer wg.Done()
+				for offset := int64(1); offset < sessionTestUsers; offset++ {
+					candidateID := (searcherID + offset) % sessionTestUsers
+					claimed, searcherFree := s.ClaimPartner(searcherID, true, candidateID)
+					if !searcherFree {
+						return
+					}
+					if claimed {
+						mu.Lock()
+						claims[searcherID]++
+						claims[candidateID]++
+						mu.Unlock()
+						return
+					}
+				}
+			}(searcherID)
+		}
+		wg.Wait()
+
+		for userID, count := range claims {
+			if count > 1 {
+				t.Fatalf("round %d: user %d was matched %d times", round, userID, count)
+			}
+		}
+		if waiting := len(s.WaitingUsers()); waiting+len(claims) != sessionTestUsers {
+			t.Fatalf("round %d: %d matched and %d waiting out of %d", round, len(claims), waiting, sessionTestUsers)
+		}
+	}
+}
+
+func TestSessionStoreExpiry(t *testing.T) {
+	s := NewSessionStore()
+
+	runConcurrently(t, func(worker, round int) {
+		userID := int64((worker + round) % sessionTestUsers)
+		// handleMessage touches every user before any handler runs
+		s.Touch(userID)
+		switch round % 8 {
+		case 0:
+			s.SetKeyboard(userID, createCancelKeyboard())
+		case 1:
+			s.SetConfessionType(userID, "text")
+		case 2:
+			s.SetComment(userID, CommentData{ConfessionID: round, UserID: userID, WaitingForComment: true})
+		case 3:
+			s.JoinWaiting(userID, time.Now())
+		case 4:
+			s.ExpireStates(time.Nanosecond)
+		case 5:
+			s.ExpireWaiting(time.Nanosecond)
+		case 6:
+			s.ExpireComments(time.Nanosecond)
+		case 7:
+			s.ExpireKeyboards(time.Nanosecond)
+		}
+	})
+
+	// Everything is idle by now, so a final sweep leaves nothing behind
+	time.Sleep(time.Millisecond)
+	s.ExpireStates(time.Nanosecond)
+	s.ExpireWaiting(time.Nanosecond)
+	s.ExpireComments(time.Nanosecond)
+	for userID := int64(0); userID < sessionTestUsers; userID++ {
+		if s.Step(userID) != "idle" || s.ConfessionType(userID) != "" || s.IsWaiting(userID) {
+			t.Errorf("user %d survived expiry", userID)
+		}
+		if _, ok := s.Comment(userID); ok {
+			t.Errorf("user %d kept a comment session", userID)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------- STRIKES & TEMPORARY BANS -----------------
+
+// Strike kinds, in the order the community guidelines escalate them
+const (
+	StrikeWarning = "warning"
+	StrikeTempBan = "temp_ban"
+	StrikePermBan = "perm_ban"
+)
+
+// nextStrikeKind follows the guideline ladder: warning, temporary ban,
+// then permanent ban for every strike after that.
+func nextStrikeKind(userID int64) string {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM strikes WHERE user_id = ?", userID).Scan(&count)
+	if err != nil {
+		log.Println("Error counting strikes:", err)
+	}
+
+	switch count {
+	case 0:
+		return StrikeWarning
+	case 1:
+		return StrikeTempBan
+	default:
+		return StrikePermBan
+	}
+}
+
+// issueStrike records the next strike on the ladder and applies it.
+func issueStrike(userID int64, reason string, source string, issuedBy int64) (string, error) {
+	kind := nextStrikeKind(userID)
+	return kind, applyStrike(userID, kind, reason, source, issuedBy, cfg.TempBanDuration.Duration)
+}
+
+// applyStrike records a strike of the given kind and applies it to the user.
+// banFor is only used for temporary bans. source is "admin" or "reports".
+func applyStrike(userID int64, kind string, reason string, source string, issuedBy int64, banFor time.Duration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	banOffset := fmt.Sprintf("+%d seconds", int(banFor.Seconds()))
+
+	switch kind {
+	case StrikeWarning:
+		_, err = tx.Exec(`
+			INSERT INTO strikes (user_id, kind, reason, source, issued_by)
+			VALUES (?, ?, ?, ?, ?)`, userID, kind, reason, source, issuedBy)
+	case StrikeTempBan:
+		_, err = tx.Exec(`
+			INSERT INTO strikes (user_id, kind, reason, source, issued_by, expires_at)
+			VALUES (?, ?, ?, ?, ?, datetime('now', ?))`, userID, kind, reason, source, issuedBy, banOffset)
+		if err == nil {
+			_, err = tx.Exec(`
+				UPDATE users SET banned = 1, ban_until = datetime('now', ?)
+				WHERE user_id = ?`, banOffset, userID)
+		}
+	case StrikePermBan:
+		_, err = tx.Exec(`
+			INSERT INTO strikes (user_id, kind, reason, source, issued_by)
+			VALUES (?, ?, ?, ?, ?)`, userID, kind, reason, source, issuedBy)
+		if err == nil {
+			_, err = tx.Exec("UPDATE users SET banned = 1, ban_until = NULL WHERE user_id = ?", userID)
+		}
+	default:
+		err = fmt.Errorf("unknown strike kind %q", kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Banned users can't keep chatting or searching; a reported user also
+	// loses the chat they were reported in
+	if kind != StrikeWarning || source == "reports" {
+		endReason := "Removed by moderators"
+		if source == "reports" {
+			endReason = "Multiple user reports"
+		}
+		endBlindChatForUser(userID, endReason)
+		if sessions.CancelWaiting(userID) {
+			deleteQueueEntry(userID)
+		}
+	}
+
+	notifyStrike(userID, kind, reason, banFor)
+	return nil
+}
+
+func notifyStrike(userID int64, kind string, reason string, banFor time.Duration) {
+	switch kind {
+	case StrikeWarning:
+		sendMessage(userID,
+			fmt.Sprintf("⚠️ *OFFICIAL WARNING*\n──────────────\n\n"+
+				"📋 *Reason:* %s\n\n"+
+				"This is your first strike. A second strike means a temporary ban.\n\n"+
+				"──────────────\n"+
+				"Please review /rules.", escapeMarkdown(reason)))
+	case StrikeTempBan:
+		sendMessage(userID,
+			fmt.Sprintf("⏳ *TEMPORARY BAN*\n──────────────\n\n"+
+				"📋 *Reason:* %s\n"+
+				"⏳ *Duration:* %s\n\n"+
+				"You'll be able to use the bot again automatically once it ends.\n"+
+				"A further strike means a permanent ban.\n\n"+
+				"──────────────\n"+
+				"📞 *Use /appeal if you think this is a mistake*",
+				escapeMarkdown(reason), formatBanDuration(banFor)))
+	case StrikePermBan:
+		sendMessage(userID,
+			fmt.Sprintf("🚫 *ACCOUNT BANNED*\n──────────────\n\n"+
+				"⛔ *Your account has been banned.*\n\n"+
+				"📋 *Reason:* %s\n"+
+				"⏳ *Duration:* Permanent\n\n"+
+				"──────────────\n"+
+				"📞 *Use /appeal if you think this is a mistake*", escapeMarkdown(reason)))
+	}
+}
+
+// unbanUser lifts both permanent and temporary bans. It reports whether the
+// user exists.
+func unbanUser(userID int64) (bool, error) {
+	result, err := db.Exec("UPDATE users SET banned = 0, ban_until = NULL WHERE user_id = ?", userID)
+	if err != nil {
+		return false, err
+	}
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
+
+// banExpiry returns when a temporary ban ends; ok is false for permanent bans.
+func banExpiry(userID int64) (time.Time, bool) {
+	var until sql.NullTime
+	err := db.QueryRow("SELECT ban_until FROM users WHERE user_id = ?", userID).Scan(&until)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("Error checking ban expiry:", err)
+	}
+	return until.Time, until.Valid
+}
+
+// getReportsSinceStrike counts reports filed after the user's last
+// report-triggered strike, so each strike needs a fresh set of reports.
+func getReportsSinceStrike(userID int64) int {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM reports
+		WHERE reported_id = ? AND created_at > COALESCE(
+			(SELECT MAX(created_at) FROM strikes WHERE user_id = ? AND source = 'reports'), '')`,
+		userID, userID).Scan(&count)
+	if err != nil {
+		log.Println("Error counting reports:", err)
+	}
+	return count
+}
+
+func strikeKindText(kind string) string {
+	switch kind {
+	case StrikeWarning:
+		return "⚠️ Warning"
+	case StrikeTempBan:
+		return "⏳ Temporary ban"
+	case StrikePermBan:
+		return "🚫 Permanent ban"
+	}
+	return kind
+}
+
+// formatBanDuration renders a ban length like "7 days" or "12 hours"
+func formatBanDuration(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		days := int(d / (24 * time.Hour))
+		if days == 1 {
+			return "1 day"
+		}
+		return fmt.Sprintf("%d days", days)
+	}
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return d.Round(time.Minute).String()
+}
+
+// parseBanDuration accepts Go durations plus a "d" suffix for days, e.g. "3d"
+func parseBanDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return d, nil
+}
+
+func cleanupExpiredBans() {
+	// Lift temporary bans whose time is up and let the users know
+	rows, err := db.Query(`
+		SELECT user_id FROM users
+		WHERE banned = 1 AND ban_until IS NOT NULL AND ban_until <= datetime('now')`)
+	if err != nil {
+		log.Println("Error loading expired bans:", err)
+		return
+	}
+
+	var expired []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err == nil {
+			expired = append(expired, userID)
+		}
+	}
+	rows.Close()
+
+	for _, userID := range expired {
+		if _, err := unbanUser(userID); err != nil {
+			log.Println("Error lifting temporary ban:", err)
+			continue
+		}
+		sendMessage(userID,
+			"✅ *Temporary Ban Over*\n──────────────\n\n"+
+				"🔓 Your access has been restored.\n\n"+
+				"⚠️ Another strike will result in a permanent ban. Please keep our /rules in mind.")
+	}
+
+	if len(expired) > 0 {
+		log.Printf("🔓 Lifted %d expired temporary bans", len(expired))
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ----------------- VOICE JOB QUEUE -----------------
+
+// VoiceJob is a voice note waiting for anonymization. Kind is "confession"
+// (goes to the admin group for review) or "blind" (goes to the chat partner).
+type VoiceJob struct {
+	ID                int64
+	Kind              string
+	UserID            int64
+	ChatID            int64
+	PartnerID         int64
+	ConfessionID      int
+	FileID            string
+	Gender            string
+	Duration          int
+	Attempts          int
+	ProgressMessageID int
+}
+
+// voiceJobWake nudges idle workers when a new job is queued
+var voiceJobWake = make(chan struct{}, 1)
+
+// enqueueVoiceJob posts the progress message the worker will keep editing in
+// the user's chat, then stores the job. The message goes first so a worker
+// can never claim the job before its ID is saved.
+func enqueueVoiceJob(job VoiceJob) (int64, error) {
+	progressMsg := tgbotapi.NewMessage(job.ChatID, "⏳ *Voice queued*\n\nWaiting for a free anonymizer...")
+	progressMsg.ParseMode = "Markdown"
+	if sent, err := bot.Send(progressMsg); err == nil {
+		job.ProgressMessageID = sent.MessageID
+	} else {
+		log.Println("Error sending voice progress message:", err)
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO voice_jobs (kind, user_id, chat_id, partner_id, file_id, gender, duration, progress_message_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.Kind, job.UserID, job.ChatID, job.PartnerID, job.FileID, job.Gender, job.Duration, job.ProgressMessageID)
+	if err != nil {
+		return 0, err
+	}
+	jobID, _ := result.LastInsertId()
+
+	select {
+	case voiceJobWake <- struct{}{}:
+	default:
+	}
+
+	return jobID, nil
+}
+
+// startVoiceWorkers requeues jobs interrupted by a restart and starts n workers.
+func startVoiceWorkers(ctx context.Context, n int) {
+	result, err := db.Exec(`
+		UPDATE voice_jobs
+		SET status = 'queued', updated_at = datetime('now')
+		WHERE status = 'processing'`)
+	if err != nil {
+		log.Println("Error requeueing voice jobs:", err)
+	} else if count, _ := result.RowsAffected(); count > 0 {
+		log.Printf("🎤 Requeued %d interrupted voice jobs", count)
+	}
+
+	for i := 0; i < n; i++ {
+		go voiceWorker(ctx, i)
+	}
+}
+
+func voiceWorker(ctx context.Context, id int) {
+	ticker := time.NewTicker(cfg.VoicePollInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		job, err := claimVoiceJob()
+		if err != nil && err != sql.ErrNoRows {
+			log.Printf("Voice worker %d failed to claim job: %v", id, err)
+		}
+
+		if job != nil {
+			runVoiceJob(id, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-voiceJobWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimVoiceJob atomically marks the oldest due job as processing.
+func claimVoiceJob() (*VoiceJob, error) {
+	var job VoiceJob
+	err := db.QueryRow(`
+		UPDATE voice_jobs
+		SET status = 'processing', attempts = attempts + 1, updated_at = datetime('now')
+		WHERE id = (
+			SELECT id FROM voice_jobs
+			WHERE status = 'queued' AND next_attempt_at <= datetime('now')
+			ORDER BY id LIMIT 1
+		)
+		RETURNING id, kind, user_id, chat_id, partner_id, confession_id, file_id,
+		          gender, duration, attempts, progress_message_id`).Scan(
+		&job.ID, &job.Kind, &job.UserID, &job.ChatID, &job.PartnerID, &job.ConfessionID, &job.FileID,
+		&job.Gender, &job.Duration, &job.Attempts, &job.ProgressMessageID)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func runVoiceJob(workerID int, job *VoiceJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Voice worker %d recovered from panic on job %d: %v\n%s", workerID, job.ID, r, debug.Stack())
+			failVoiceJob(job, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	log.Printf("🎤 Voice worker %d processing job %d (attempt %d)", workerID, job.ID, job.Attempts)
+
+	audio, pitchFactor, err := anonymizeVoice(job.FileID, job.Gender, func(step int, text string) {
+		updateVoiceProgress(job, fmt.Sprintf("🔊 *Anonymizing your voice...*\n\nSTEP %d/3: %s", step, text))
+	})
+	if err != nil {
+		failVoiceJob(job, err)
+		return
+	}
+
+	voice := tgbotapi.FileBytes{Name: "anonymized_voice.ogg", Bytes: audio}
+
+	var resultFileID string
+	switch job.Kind {
+	case "confession":
+		resultFileID, err = deliverVoiceConfession(job, voice)
+	case "blind":
+		resultFileID, err = deliverBlindVoice(job, voice)
+	default:
+		err = fmt.Errorf("unknown voice job kind %q", job.Kind)
+	}
+	if err != nil {
+		failVoiceJob(job, err)
+		return
+	}
+
+	_, err = db.Exec(`
+		UPDATE voice_jobs
+		SET status = 'done', result_file_id = ?, last_error = NULL, updated_at = datetime('now')
+		WHERE id = ?`, resultFileID, job.ID)
+	if err != nil {
+		log.Println("Error completing voice job:", err)
+	}
+	log.Printf("🎤 Voice job %d done (pitch %s)", job.ID, pitchFactor)
+}
+
+func deliverVoiceConfession(job *VoiceJob, voice tgbotapi.FileBytes) (string, error) {
+	// Create the confession once so retries don't leave duplicates behind
+	if job.ConfessionID == 0 {
+		confessionID, err := saveVoiceConfession(job.UserID, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to save voice confession: %v", err)
+		}
+		job.ConfessionID = int(confessionID)
+		db.Exec("UPDATE voice_jobs SET confession_id = ? WHERE id = ?", job.ConfessionID, job.ID)
+	}
+
+	// A previous attempt may have reached the admins before failing; never
+	// post a second review card
+	var adminMessageID sql.NullInt64
+	var voiceID sql.NullString
+	err := db.QueryRow("SELECT admin_message_id, voice_id FROM confessions WHERE id = ?", job.ConfessionID).Scan(
+		&adminMessageID, &voiceID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load voice confession: %v", err)
+	}
+	if adminMessageID.Valid && adminMessageID.Int64 != 0 {
+		updateVoiceProgress(job, "✅ *Voice anonymized*\n\nYour voice confession was sent for review.")
+		return voiceID.String, nil
+	}
+
+	// Send to admin for approval with the ANONYMIZED voice
+	msg, err := sendVoiceToAdmin(job.ConfessionID, job.UserID, voice, job.Duration)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload processed voice: %v", err)
+	}
+
+	// The card is out, so nothing after this may trigger a retry
+	fileID := ""
+	if msg.Voice != nil {
+		fileID = msg.Voice.FileID
+	} else {
+		log.Printf("No voice in admin message for confession #%d", job.ConfessionID)
+	}
+	_, err = db.Exec("UPDATE confessions SET voice_id = ?, admin_message_id = ? WHERE id = ?",
+		fileID, msg.MessageID, job.ConfessionID)
+	if err != nil {
+		log.Printf("Error saving voice ID for confession #%d: %v", job.ConfessionID, err)
+	}
+
+	updateVoiceProgress(job, "✅ *Voice anonymized*\n\nYour voice confession was sent for review.")
+	sendConfessionSubmittedMessage(job.ChatID, "voice")
+	return fileID, nil
+}
+
+func deliverBlindVoice(job *VoiceJob, voice tgbotapi.FileBytes) (string, error) {
+	partner, ok := sessions.Partner(job.UserID)
+	if !ok || partner.PartnerID != job.PartnerID {
+		// The chat ended while we were processing; nothing to retry
+		updateVoiceProgress(job, "⚠️ *Voice not delivered*\n\nThe chat ended before your voice was ready.")
+		return "", nil
+	}
+
+	romanticKeyboard := createRomanticChatKeyboard()
+	voiceMsg := tgbotapi.NewVoice(partner.PartnerID, voice)
+	voiceMsg.Caption = fmt.Sprintf("🎤 *Voice from %s*", partner.PartnerUsername)
+	voiceMsg.ReplyMarkup = romanticKeyboard
+	sent, err := bot.Send(voiceMsg)
+	if err != nil {
+		return "", fmt.Errorf("failed to deliver voice: %v", err)
+	}
+
+	updateVoiceProgress(job, "✅ *Voice delivered*\n\nYour anonymized voice reached your partner.")
+
+	// Send tip to partner
+	sendMessageWithKeyboard(partner.PartnerID,
+		"💡 *Tip:* Voice messages are anonymized for privacy using Rubber Band!",
+		romanticKeyboard)
+
+	if sent.Voice != nil {
+		return sent.Voice.FileID, nil
+	}
+	return "", nil
+}
+
+// failVoiceJob schedules a retry with exponential backoff, or gives up after
+// the configured number of attempts and tells the user and the admin group.
+func failVoiceJob(job *VoiceJob, jobErr error) {
+	log.Printf("Voice job %d attempt %d failed: %v", job.ID, job.Attempts, jobErr)
+
+	if job.Attempts < cfg.VoiceMaxAttempts {
+		backoff := cfg.VoiceRetryBackoff.Duration * time.Duration(1<<uint(job.Attempts-1))
+		_, err := db.Exec(`
+			UPDATE voice_jobs
+			SET status = 'queued', last_error = ?, updated_at = datetime('now'),
+			    next_attempt_at = datetime('now', ?)
+			WHERE id = ?`,
+			jobErr.Error(), fmt.Sprintf("+%d seconds", int(backoff.Seconds())), job.ID)
+		if err != nil {
+			log.Println("Error rescheduling voice job:", err)
+		}
+		updateVoiceProgress(job, fmt.Sprintf("⚠️ *Processing hiccup*\n\nRetrying in %s (attempt %d of %d)...",
+			backoff.Round(time.Second), job.Attempts+1, cfg.VoiceMaxAttempts))
+		return
+	}
+
+	_, err := db.Exec(`
+		UPDATE voice_jobs
+		SET status = 'failed', last_error = ?, updated_at = datetime('now')
+		WHERE id = ?`, jobErr.Error(), job.ID)
+	if err != nil {
+		log.Println("Error failing voice job:", err)
+	}
+
+	if job.Kind == "blind" {
+		sendOriginalBlindVoice(job)
+	} else {
+		updateVoiceProgress(job, "❌ *Voice Processing Failed*\n\nPlease try again or use text confession.")
+	}
+
+	sendMessage(adminGroupID,
+		fmt.Sprintf("❌ *VOICE JOB FAILED* #%d\n──────────────\n\n"+
+			"📊 *Type:* %s\n"+
+			"👤 *User ID:* `%d`\n"+
+			"🔁 *Attempts:* %d\n"+
+			"⚠️ *Error:* `%s`",
+			job.ID, job.Kind, job.UserID, job.Attempts, jobErr.Error()))
+}
+
+// sendOriginalBlindVoice keeps the blind chat flowing when anonymization
+// keeps failing, the same fallback the chat used before the job queue.
+func sendOriginalBlindVoice(job *VoiceJob) {
+	partner, ok := sessions.Partner(job.UserID)
+	if !ok || partner.PartnerID != job.PartnerID {
+		updateVoiceProgress(job, "⚠️ *Voice not delivered*\n\nThe chat ended before your voice was ready.")
+		return
+	}
+
+	voiceMsg := tgbotapi.NewVoice(partner.PartnerID, tgbotapi.FileID(job.FileID))
+	voiceMsg.Caption = fmt.Sprintf("🎤 *Voice from %s*", partner.PartnerUsername)
+	voiceMsg.ReplyMarkup = createRomanticChatKeyboard()
+	bot.Send(voiceMsg)
+
+	updateVoiceProgress(job, "⚠️ *Voice sent without anonymization*\n\nThe anonymizer failed, so your original voice was delivered.")
+}
+
+func updateVoiceProgress(job *VoiceJob, text string) {
+	if job.ProgressMessageID == 0 {
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(job.ChatID, job.ProgressMessageID, text)
+	edit.ParseMode = "Markdown"
+	if _, err := bot.Send(edit); err != nil {
+		log.Println("Error updating voice progress:", err)
+	}
+}